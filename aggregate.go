@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// classGroups maps a building or faculty name to the classes whose schedules
+// are aggregated for it, configured as DLU_CLASS_GROUPS="A=CTK45,CTK46;B=QTK45".
+var classGroups = loadClassGroups(getenv("DLU_CLASS_GROUPS"))
+
+func loadClassGroups(raw string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, entry := range strings.Split(raw, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			configError("DLU_CLASS_GROUPS entry %q: expected NAME=CLASS,CLASS", entry)
+			continue
+		}
+		var ids []string
+		for _, id := range strings.Split(list, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			groups[strings.TrimSpace(name)] = ids
+		}
+	}
+	return groups
+}
+
+const aggregateWorkers = 4
+
+type classResult struct {
+	Schedule dluparser.Schedule
+	Err      error
+}
+
+// fetchClasses loads the same week for several classes concurrently.
+func fetchClasses(ctx context.Context, base scheduleQuery, classIDs []string) map[string]classResult {
+	results := make(map[string]classResult, len(classIDs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, aggregateWorkers)
+
+	for _, id := range classIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			q := base
+			q.ClassStudentID = id
+			s, err := loadSchedule(ctx, q)
+
+			mu.Lock()
+			results[id] = classResult{Schedule: s, Err: err}
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return results
+}
+
+type roomOccupancy struct {
+	Free     []string `json:"free"`
+	Occupied []string `json:"occupied"`
+}
+
+// roomsAt splits every room seen in the schedules into those used on day at
+// the given period and those that are free then. Rooms that never appear in
+// any schedule are unknown and not reported.
+func roomsAt(schedules []dluparser.Schedule, day string, period int) roomOccupancy {
+	known := make(map[string]bool)
+	busy := make(map[string]bool)
+	for _, s := range schedules {
+		for name, d := range s.Days {
+			sameDay := sameWeekday(name, day)
+			for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+				for _, sub := range slot {
+					if sub.Room == "" {
+						continue
+					}
+					known[sub.Room] = true
+					if !sameDay {
+						continue
+					}
+					if slices.Contains(sub.Periods, period) {
+						busy[sub.Room] = true
+					}
+				}
+			}
+		}
+	}
+
+	occ := roomOccupancy{Free: []string{}, Occupied: []string{}}
+	for room := range known {
+		if busy[room] {
+			occ.Occupied = append(occ.Occupied, room)
+		} else {
+			occ.Free = append(occ.Free, room)
+		}
+	}
+	sort.Strings(occ.Free)
+	sort.Strings(occ.Occupied)
+	return occ
+}
+
+// utilization counts scheduled periods per room and per teacher across a set
+// of schedules.
+type utilization struct {
+	Rooms    map[string]int `json:"rooms"`
+	Teachers map[string]int `json:"teachers"`
+}
+
+func computeUtilization(schedules map[string]dluparser.Schedule) utilization {
+	u := utilization{Rooms: map[string]int{}, Teachers: map[string]int{}}
+	for _, s := range schedules {
+		for _, d := range s.Days {
+			for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+				for _, sub := range slot {
+					if sub.Room != "" {
+						u.Rooms[sub.Room] += len(sub.Periods)
+					}
+					if sub.Teacher != "" {
+						u.Teachers[sub.Teacher] += len(sub.Periods)
+					}
+				}
+			}
+		}
+	}
+	return u
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeysFile enables API keys. Keys live in this JSON file with their limits
+// and usage counters; only a SHA-256 of each key is stored, so a key can only
+// be read once, in the response that created it.
+var (
+	apiKeysFile    = getenv("DLU_API_KEYS_FILE")
+	apiKeyRequired = envBool("DLU_API_KEY_REQUIRED", false)
+)
+
+type apiKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Hash      string     `json:"hash,omitempty"`
+	RateLimit float64    `json:"rate_limit"`
+	Burst     int        `json:"burst"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Requests  int64      `json:"requests"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+	bucket    *tokenBucket
+}
+
+type keyStore struct {
+	mu     sync.Mutex
+	path   string
+	keys   map[string]*apiKey
+	byHash map[string]*apiKey
+	// dirty is set when usage changed since the file was last written;
+	// counters are flushed periodically rather than on every request.
+	dirty bool
+}
+
+var apiKeys *keyStore
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func openKeyStore(path string) (*keyStore, error) {
+	s := &keyStore{path: path, keys: map[string]*apiKey{}, byHash: map[string]*apiKey{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []*apiKey
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		s.add(k)
+	}
+	return s, nil
+}
+
+func (s *keyStore) add(k *apiKey) {
+	k.bucket = newTokenBucket(k.RateLimit, k.Burst)
+	s.keys[k.ID] = k
+	s.byHash[k.Hash] = k
+}
+
+// save rewrites the file through a temporary one so a crash never leaves it
+// half written. The caller holds s.mu.
+func (s *keyStore) save() error {
+	keys := s.sorted()
+	b, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func (s *keyStore) sorted() []*apiKey {
+	keys := make([]*apiKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// create stores a new key and returns it along with the secret, which is not
+// kept.
+func (s *keyStore) create(name string, rate float64, burst int) (string, apiKey, error) {
+	raw := "dlu_" + randomHex(24)
+	k := &apiKey{
+		ID:        randomHex(8),
+		Name:      name,
+		Hash:      hashKey(raw),
+		RateLimit: rate,
+		Burst:     burst,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(k)
+	if err := s.save(); err != nil {
+		delete(s.keys, k.ID)
+		delete(s.byHash, k.Hash)
+		return "", apiKey{}, err
+	}
+	return raw, k.public(), nil
+}
+
+func (s *keyStore) revoke(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return false, nil
+	}
+	if k.RevokedAt == nil {
+		now := time.Now().UTC()
+		k.RevokedAt = &now
+	}
+	return true, s.save()
+}
+
+func (s *keyStore) list() []apiKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []apiKey{}
+	for _, k := range s.sorted() {
+		out = append(out, k.public())
+	}
+	return out
+}
+
+// public is the key as shown to admins, without its hash.
+func (k *apiKey) public() apiKey {
+	p := *k
+	p.Hash = ""
+	p.bucket = nil
+	return p
+}
+
+// use counts a request made with raw and spends a token from the key's
+// bucket. It returns nil for unknown or revoked keys.
+func (s *keyStore) use(raw string) (*apiKey, bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.byHash[hashKey(raw)]
+	if !ok || k.RevokedAt != nil {
+		return nil, false, 0
+	}
+	now := time.Now()
+	used := now.UTC()
+	k.Requests++
+	k.LastUsed = &used
+	s.dirty = true
+	ok, wait := k.bucket.take(now)
+	return k, ok, wait
+}
+
+func (s *keyStore) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *keyStore) flushEvery(d time.Duration) {
+	for range time.Tick(d) {
+		if err := s.flush(); err != nil {
+			log.Printf("api keys: %v", err)
+		}
+	}
+}
+
+// apiKeyAuth checks X-API-Key when DLU_API_KEYS_FILE is set. Keyed requests
+// are limited per key instead of per IP; requests without a key are refused
+// only if DLU_API_KEY_REQUIRED is set. Health checks and admin endpoints,
+// which have their own key, are always let through.
+func apiKeyAuth(c *gin.Context) {
+	path := c.Request.URL.Path
+	if apiKeys == nil || path == "/healthz" || path == "/readyz" || strings.HasPrefix(path, "/admin/") {
+		c.Next()
+		return
+	}
+	raw := c.GetHeader("X-API-Key")
+	if raw == "" {
+		if apiKeyRequired {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			return
+		}
+		c.Next()
+		return
+	}
+
+	key, ok, wait := apiKeys.use(raw)
+	if key == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return
+	}
+	c.Set("apiKey", key.ID)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+		return
+	}
+	c.Next()
+}
+
+type createKeyRequest struct {
+	Name      string  `json:"name"`
+	RateLimit float64 `json:"rate_limit"`
+	Burst     int     `json:"burst"`
+}
+
+func createKeyHandler(c *gin.Context) {
+	if apiKeys == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "API keys are not enabled"})
+		return
+	}
+	req := createKeyRequest{RateLimit: rateLimit, Burst: rateBurst}
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.RateLimit <= 0 || req.Burst <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rate_limit and burst must be positive"})
+		return
+	}
+
+	raw, key, err := apiKeys.create(req.Name, req.RateLimit, req.Burst)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"id":         key.ID,
+		"name":       key.Name,
+		"key":        raw,
+		"rate_limit": key.RateLimit,
+		"burst":      key.Burst,
+		"created_at": key.CreatedAt,
+	})
+}
+
+func listKeysHandler(c *gin.Context) {
+	if apiKeys == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "API keys are not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": apiKeys.list()})
+}
+
+func revokeKeyHandler(c *gin.Context) {
+	if apiKeys == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "API keys are not enabled"})
+		return
+	}
+	ok, err := apiKeys.revoke(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown API key"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+const maxBatchClasses = 50
+
+type batchRequest struct {
+	YearStudy       string   `json:"YearStudy"`
+	TermID          string   `json:"TermID"`
+	Week            string   `json:"Week"`
+	Semester        string   `json:"semester"`
+	ClassStudentIDs []string `json:"ClassStudentIDs"`
+}
+
+type batchResponse struct {
+	Schedules map[string]dluparser.Schedule `json:"schedules"`
+	Errors    map[string]string             `json:"errors"`
+}
+
+// batchHandler fetches one week for several classes. GET takes a
+// comma-separated ClassStudentID; POST takes a batchRequest body.
+func batchHandler(c *gin.Context) {
+	var q scheduleQuery
+	var ids []string
+	var err error
+
+	if c.Request.Method == http.MethodPost {
+		var req batchRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		q, err = scheduleQuery{YearStudy: req.YearStudy, TermID: req.TermID, Week: req.Week, Semester: req.Semester}.resolve()
+		ids = req.ClassStudentIDs
+	} else {
+		q, err = bindWeekQuery(c)
+		ids = strings.Split(c.Query("ClassStudentID"), ",")
+	}
+	if err == nil {
+		ids, err = normalizeClassIDs(ids)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ids) > maxBatchClasses {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d classes per batch", maxBatchClasses)})
+		return
+	}
+
+	resp := batchResponse{Schedules: map[string]dluparser.Schedule{}, Errors: map[string]string{}}
+	for id, res := range fetchClasses(c.Request.Context(), q, ids) {
+		if res.Err != nil {
+			resp.Errors[id] = res.Err.Error()
+			continue
+		}
+		resp.Schedules[id] = res.Schedule
+	}
+	c.JSON(http.StatusOK, resp)
+}
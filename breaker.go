@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	breakerFailures = envInt("DLU_BREAKER_FAILURES", 5)
+	breakerCooldown = envDuration("DLU_BREAKER_COOLDOWN", 30*time.Second)
+)
+
+// errCircuitOpen is returned without contacting the portal while the breaker
+// is open; handlers answer it with 503 and Retry-After.
+var errCircuitOpen = errors.New("upstream unavailable, circuit open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	return [...]string{"closed", "open", "half-open"}[s]
+}
+
+// breaker trips after DLU_BREAKER_FAILURES consecutive failed fetches and
+// rejects requests for DLU_BREAKER_COOLDOWN. After that a single probe is let
+// through: success closes the breaker, failure opens it again.
+type breaker struct {
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+var upstreamBreaker = &breaker{}
+
+// openError carries how long until the breaker will allow a probe.
+type openError struct {
+	retryAfter time.Duration
+}
+
+func (e *openError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", errCircuitOpen, e.retryAfter.Round(time.Second))
+}
+
+func (e *openError) Unwrap() error { return errCircuitOpen }
+
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		wait := breakerCooldown - time.Since(b.openedAt)
+		if wait > 0 {
+			return &openError{retryAfter: wait}
+		}
+		b.state = breakerHalfOpen
+		fallthrough
+	case breakerHalfOpen:
+		if b.probing {
+			return &openError{retryAfter: time.Second}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record reports a fetch's outcome. Failures the portal isn't to blame for,
+// such as the client going away or a 4xx, count as successes.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := err != nil && (transient(err) || errors.Is(err, errUpstreamTimeout))
+	if b.state == breakerHalfOpen {
+		b.probing = false
+	}
+	if !failed {
+		b.state, b.failures = breakerClosed, 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= breakerFailures {
+		b.state, b.openedAt = breakerOpen, time.Now()
+	}
+}
+
+func (b *breaker) status() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cached weeks are fresh for DLU_CACHE_TTL. For DLU_CACHE_STALE_WHILE_REVALIDATE
+// after that they are still served at once while a refresh runs in the
+// background, and for DLU_CACHE_STALE_IF_ERROR they stand in for a fetch
+// that fails.
+var (
+	cacheTTL          = envDuration("DLU_CACHE_TTL", 5*time.Minute)
+	cacheRevalidate   = envDuration("DLU_CACHE_STALE_WHILE_REVALIDATE", time.Minute)
+	cacheStaleIfError = envDuration("DLU_CACHE_STALE_IF_ERROR", 7*24*time.Hour)
+	cacheMaxEntries   = envInt("DLU_CACHE_MAX_ENTRIES", 10000)
+)
+
+type cacheEntry struct {
+	schedule  dluparser.Schedule
+	fetchedAt time.Time
+}
+
+type scheduleCache struct {
+	mu         sync.Mutex
+	entries    map[scheduleQuery]cacheEntry
+	refreshing map[scheduleQuery]bool
+}
+
+var weekCache = &scheduleCache{
+	entries:    make(map[scheduleQuery]cacheEntry),
+	refreshing: make(map[scheduleQuery]bool),
+}
+
+func cacheKey(q scheduleQuery) scheduleQuery {
+	q.Semester = ""
+	return q
+}
+
+func (c *scheduleCache) get(q scheduleQuery) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[cacheKey(q)]
+	return e, ok
+}
+
+func (c *scheduleCache) put(q scheduleQuery, s dluparser.Schedule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= cacheMaxEntries {
+		var oldest scheduleQuery
+		var oldestAt time.Time
+		for k, e := range c.entries {
+			if now.Sub(e.fetchedAt) > cacheTTL+cacheStaleIfError {
+				delete(c.entries, k)
+			} else if oldestAt.IsZero() || e.fetchedAt.Before(oldestAt) {
+				oldest, oldestAt = k, e.fetchedAt
+			}
+		}
+		if len(c.entries) >= cacheMaxEntries {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[cacheKey(q)] = cacheEntry{schedule: s, fetchedAt: now}
+}
+
+// served returns a copy of the cached schedule marked with how it was
+// served; entries are shared, so Meta must not be modified in place.
+func (e cacheEntry) served(status string) dluparser.Schedule {
+	s := e.schedule
+	meta := *s.Meta
+	meta.Cache = status
+	meta.Stale = status == "stale"
+	s.Meta = &meta
+	return s
+}
+
+// revalidate refreshes an entry in the background, at most once at a time
+// per week.
+func (c *scheduleCache) revalidate(q scheduleQuery) {
+	key := cacheKey(q)
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		if _, err := fetchShared(context.Background(), q); err != nil {
+			log.Printf("cache: revalidate %s week %s: %v", q.ClassStudentID, q.Week, err)
+		}
+	}()
+}
+
+// loadSchedule returns a week through the cache.
+func loadSchedule(ctx context.Context, q scheduleQuery) (dluparser.Schedule, error) {
+	_, span := tracer.Start(ctx, "cache.lookup")
+	e, cached := weekCache.get(q)
+	age := time.Since(e.fetchedAt)
+	switch {
+	case cached && age < cacheTTL:
+		span.SetAttributes(cacheAttr.String("hit"))
+		span.End()
+		return e.served("hit"), nil
+	case cached && age < cacheTTL+cacheRevalidate:
+		span.SetAttributes(cacheAttr.String("stale"))
+		span.End()
+		weekCache.revalidate(q)
+		return e.served("stale"), nil
+	}
+	span.SetAttributes(cacheAttr.String("miss"))
+	span.End()
+
+	s, err := fetchShared(ctx, q)
+	if err != nil {
+		if cached && age < cacheTTL+cacheStaleIfError && ctx.Err() == nil {
+			return e.served("stale"), nil
+		}
+		return s, err
+	}
+	return cacheEntry{schedule: s}.served("miss"), nil
+}
+
+// fetches coalesces concurrent fetches of the same week, so a class checking
+// its timetable at once costs one upstream request and one parse.
+var fetches singleflight.Group
+
+// fetchShared fetches a week and caches it, joining a fetch of the same week
+// already in flight. The fetch itself isn't cancelled when one waiting
+// client goes away, since others may still want the result.
+func fetchShared(ctx context.Context, q scheduleQuery) (dluparser.Schedule, error) {
+	k := cacheKey(q)
+	key := strings.Join([]string{k.Provider, k.YearStudy, k.TermID, k.Week, k.ClassStudentID}, "|")
+	ch := fetches.DoChan(key, func() (any, error) {
+		s, err := fetchSchedule(context.WithoutCancel(ctx), q)
+		if err == nil {
+			weekCache.put(q, s)
+		}
+		return s, err
+	})
+	select {
+	case r := <-ch:
+		return r.Val.(dluparser.Schedule), r.Err
+	case <-ctx.Done():
+		return dluparser.Schedule{}, ctx.Err()
+	}
+}
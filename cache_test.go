@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheStatus(t *testing.T) {
+	prevTTL, prevRevalidate, prevStale := cacheTTL, cacheRevalidate, cacheStaleIfError
+	t.Cleanup(func() { cacheTTL, cacheRevalidate, cacheStaleIfError = prevTTL, prevRevalidate, prevStale })
+	page := servePage(t, "mau2.html")
+
+	tests := []struct {
+		name                     string
+		ttl, revalidate, ifError time.Duration
+		// failAfter makes the portal answer 404 once it has served the page
+		// this many times; 0 never fails.
+		failAfter int32
+		requests  int
+		want      int
+		cache     string
+	}{
+		{"miss", time.Hour, 0, 0, 0, 1, http.StatusOK, "miss"},
+		{"hit", time.Hour, 0, 0, 0, 2, http.StatusOK, "hit"},
+		{"stale while revalidating", 0, time.Hour, 0, 0, 2, http.StatusOK, "stale"},
+		{"stale if error", 0, 0, time.Hour, 1, 2, http.StatusOK, "stale"},
+		{"too old to serve on error", 0, 0, 0, 1, 2, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		cacheTTL, cacheRevalidate, cacheStaleIfError = tt.ttl, tt.revalidate, tt.ifError
+		var served atomic.Int32
+		h := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if tt.failAfter > 0 && served.Load() >= tt.failAfter {
+				http.NotFound(w, r)
+				return
+			}
+			served.Add(1)
+			page(w, r)
+		})
+		var rec *httptest.ResponseRecorder
+		for i := 0; i < tt.requests; i++ {
+			rec = get(t, h, "/dlu?"+weekParams+"&ClassStudentID=CTK45")
+		}
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+		}
+		if got := rec.Header().Get("X-Cache"); got != tt.cache {
+			t.Errorf("%s: X-Cache %q, want %q", tt.name, got, tt.cache)
+		}
+		waitRevalidated(t)
+	}
+}
+
+// waitRevalidated waits for background revalidations to finish, so none
+// outlives the test server it fetches from.
+func waitRevalidated(t *testing.T) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		weekCache.mu.Lock()
+		n := len(weekCache.refreshing)
+		weekCache.mu.Unlock()
+		if n == 0 {
+			return
+		}
+	}
+	t.Fatal("revalidation still running")
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// campusTZ is Đà Lạt local time (UTC+7, no daylight saving).
+var campusTZ = time.FixedZone("ICT", 7*60*60)
+
+// weekOneStarts holds the Monday of week 1 per academic year, configured as
+// DLU_WEEK_ONE="2025-2026=2025-01-06;...". Years without an entry fall back
+// to ISO week numbering of the first calendar year, which matches the week
+// numbers the portal uses (HK01 of 2025-2026 starts around week 38).
+var weekOneStarts = loadWeekOneStarts(getenv("DLU_WEEK_ONE"))
+
+func loadWeekOneStarts(raw string) map[string]time.Time {
+	starts := make(map[string]time.Time)
+	for _, entry := range strings.Split(raw, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		year, date, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(date), campusTZ)
+		if !ok || err != nil {
+			configError("DLU_WEEK_ONE entry %q: expected YEAR=YYYY-MM-DD", entry)
+			continue
+		}
+		if t.Weekday() != time.Monday {
+			configError("DLU_WEEK_ONE entry %q: %s is not a Monday", entry, date)
+			continue
+		}
+		starts[strings.TrimSpace(year)] = t
+	}
+	return starts
+}
+
+// learnedWeekOne holds week 1 Mondays worked out from the portal's week
+// picker by /dlu/calendar, used for years DLU_WEEK_ONE doesn't cover.
+var (
+	learnedMu      sync.Mutex
+	learnedWeekOne = make(map[string]time.Time)
+)
+
+func learnWeekOne(yearStudy string, week int, monday time.Time) {
+	if _, ok := weekOneStarts[yearStudy]; ok || monday.Weekday() != time.Monday {
+		return
+	}
+	learnedMu.Lock()
+	learnedWeekOne[yearStudy] = monday.AddDate(0, 0, -7*(week-1))
+	learnedMu.Unlock()
+}
+
+// weekStart returns the Monday (00:00 campus time) of the given week.
+func weekStart(yearStudy string, week int) (time.Time, error) {
+	if t, ok := weekOneStarts[yearStudy]; ok {
+		return t.AddDate(0, 0, 7*(week-1)), nil
+	}
+	learnedMu.Lock()
+	t, ok := learnedWeekOne[yearStudy]
+	learnedMu.Unlock()
+	if ok {
+		return t.AddDate(0, 0, 7*(week-1)), nil
+	}
+
+	first, _, ok := strings.Cut(yearStudy, "-")
+	year, err := strconv.Atoi(first)
+	if !ok || err != nil {
+		return time.Time{}, fmt.Errorf("invalid YearStudy %q", yearStudy)
+	}
+
+	// January 4th is always in ISO week 1.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, campusTZ)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, 7*(week-1)), nil
+}
+
+// weekAt returns the week number of yearStudy that contains t.
+func weekAt(yearStudy string, t time.Time) (int, error) {
+	first, err := weekStart(yearStudy, 1)
+	if err != nil {
+		return 0, err
+	}
+	days := int(t.In(campusTZ).Sub(first).Hours() / 24)
+	if days < 0 {
+		return 0, fmt.Errorf("%s has not started yet", yearStudy)
+	}
+	return days/7 + 1, nil
+}
+
+type calendarWeek struct {
+	Week    int    `json:"week"`
+	Label   string `json:"label,omitempty"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Current bool   `json:"current,omitempty"`
+}
+
+var labelDateRe = regexp.MustCompile(`(\d{1,2})/(\d{1,2})/(\d{4})`)
+
+// labelDates reads the first two dd/mm/yyyy dates of a week picker label
+// such as "Tuần 38 [15/09/2025 -- 21/09/2025]".
+func labelDates(label string) (start, end time.Time, ok bool) {
+	m := labelDateRe.FindAllStringSubmatch(label, 2)
+	if len(m) < 2 {
+		return start, end, false
+	}
+	parse := func(d []string) (time.Time, error) {
+		return time.ParseInLocation("2/1/2006", d[1]+"/"+d[2]+"/"+d[3], campusTZ)
+	}
+	start, err1 := parse(m[0])
+	end, err2 := parse(m[1])
+	return start, end, err1 == nil && err2 == nil
+}
+
+func calendarEntry(yearStudy string, week int, label string) (calendarWeek, error) {
+	start, end, ok := labelDates(label)
+	if ok {
+		learnWeekOne(yearStudy, week, start)
+	} else {
+		var err error
+		if start, err = weekStart(yearStudy, week); err != nil {
+			return calendarWeek{}, err
+		}
+		end = start.AddDate(0, 0, 6)
+	}
+	return calendarWeek{Week: week, Label: label, Start: start.Format("2006-01-02"), End: end.Format("2006-01-02")}, nil
+}
+
+// portalCalendar lists the term's weeks from the portal's week picker. It
+// returns nil when the page has no week picker.
+func portalCalendar(ctx context.Context, q scheduleQuery) ([]calendarWeek, error) {
+	selects, err := portalSelects(ctx, q.YearStudy, q.TermID)
+	if err != nil {
+		return nil, err
+	}
+	var weeks []calendarWeek
+	for _, o := range pickSelect(selects, "Week", "WeekID") {
+		week, err := strconv.Atoi(o.Value)
+		if err != nil || week < 1 {
+			continue
+		}
+		w, err := calendarEntry(q.YearStudy, week, o.Label)
+		if err != nil {
+			return nil, err
+		}
+		weeks = append(weeks, w)
+	}
+	return weeks, nil
+}
+
+// computedCalendar covers the whole academic year, August to July, since the
+// term boundaries are only known to the portal.
+func computedCalendar(yearStudy string) ([]calendarWeek, error) {
+	first, _, _ := strings.Cut(yearStudy, "-")
+	year, err := strconv.Atoi(first)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YearStudy %q", yearStudy)
+	}
+	from, err := weekAt(yearStudy, time.Date(year, time.August, 1, 0, 0, 0, 0, campusTZ))
+	if err != nil {
+		from = 1
+	}
+	to, err := weekAt(yearStudy, time.Date(year+1, time.July, 31, 0, 0, 0, 0, campusTZ))
+	if err != nil {
+		return nil, err
+	}
+	var weeks []calendarWeek
+	for week := from; week <= to; week++ {
+		w, err := calendarEntry(yearStudy, week, "")
+		if err != nil {
+			return nil, err
+		}
+		weeks = append(weeks, w)
+	}
+	return weeks, nil
+}
+
+// calendarHandler maps the term's week numbers to dates, from the portal's
+// week picker when DLU_OPTIONS_URL is set and otherwise from the same
+// numbering the ISO dates and iCalendar export use.
+func calendarHandler(c *gin.Context) {
+	q := queryFromRequest(c)
+	q.ClassStudentID, q.Week = "", "1"
+	q, err := q.resolve()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source := "portal"
+	weeks, err := portalCalendar(c.Request.Context(), q)
+	if errors.Is(err, errOptionsNotConfigured) {
+		err = nil
+	}
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+	if len(weeks) == 0 {
+		source = "computed"
+		if weeks, err = computedCalendar(q.YearStudy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	today := time.Now().In(campusTZ).Format("2006-01-02")
+	for i := range weeks {
+		weeks[i].Current = weeks[i].Start <= today && today <= weeks[i].End
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"year_study": q.YearStudy,
+		"term_id":    q.TermID,
+		"source":     source,
+		"weeks":      weeks,
+	})
+}
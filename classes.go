@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+type facultyClasses struct {
+	Faculty string   `json:"faculty"`
+	Classes []string `json:"classes"`
+}
+
+var errClassesNotConfigured = errors.New("Class list source is not configured")
+
+// classPrefixRe takes the faculty part of a class code, "CTK" in "CTK45A",
+// for pickers that don't group their classes.
+var classPrefixRe = regexp.MustCompile(`^\p{L}+`)
+
+func sortedFaculties(byFaculty map[string][]string) []facultyClasses {
+	out := make([]facultyClasses, 0, len(byFaculty))
+	for name, ids := range byFaculty {
+		sort.Strings(ids)
+		out = append(out, facultyClasses{Faculty: name, Classes: ids})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Faculty < out[j].Faculty })
+	return out
+}
+
+// discoverClasses lists the term's classes by faculty, from the class picker
+// on the DLU_OPTIONS_URL page or, failing that, from DLU_CLASS_GROUPS.
+func discoverClasses(ctx context.Context, q scheduleQuery) ([]facultyClasses, string, error) {
+	if optionsURL != "" {
+		selects, err := portalSelects(ctx, q.YearStudy, q.TermID)
+		if err != nil {
+			return nil, "", err
+		}
+		byFaculty := make(map[string][]string)
+		seen := make(map[string]bool)
+		for _, o := range pickSelect(selects, "ClassStudentID", "ClassID") {
+			id := dluparser.NormalizeClassCode(o.Value)
+			if !classIDRe.MatchString(id) || seen[id] {
+				continue
+			}
+			seen[id] = true
+			faculty := o.Group
+			if faculty == "" {
+				faculty = classPrefixRe.FindString(id)
+			}
+			byFaculty[faculty] = append(byFaculty[faculty], id)
+		}
+		if len(seen) > 0 {
+			return sortedFaculties(byFaculty), "portal", nil
+		}
+	}
+	if len(classGroups) > 0 {
+		byFaculty := make(map[string][]string, len(classGroups))
+		for name, ids := range classGroups {
+			if ids, err := normalizeClassIDs(ids); err == nil {
+				byFaculty[name] = ids
+			}
+		}
+		return sortedFaculties(byFaculty), "configured", nil
+	}
+	return nil, "", errClassesNotConfigured
+}
+
+// discoveredClassIDs is every class discoverClasses finds, across faculties.
+func discoveredClassIDs(ctx context.Context, q scheduleQuery) ([]string, error) {
+	faculties, _, err := discoverClasses(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, f := range faculties {
+		ids = append(ids, f.Classes...)
+	}
+	sort.Strings(ids)
+	return slices.Compact(ids), nil
+}
+
+// bindOptionalTerm resolves year and term when any of them is given, for
+// endpoints where they only narrow what the portal lists.
+func bindOptionalTerm(c *gin.Context) (scheduleQuery, error) {
+	q := queryFromRequest(c)
+	q.ClassStudentID = ""
+	if q.Semester == "" && q.YearStudy == "" && q.TermID == "" {
+		return q, nil
+	}
+	q.Week = "1"
+	q, err := q.resolve()
+	q.Week = ""
+	return q, err
+}
+
+func classesHandler(c *gin.Context) {
+	q, err := bindOptionalTerm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	faculties, source, err := discoverClasses(c.Request.Context(), q)
+	if errors.Is(err, errClassesNotConfigured) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+	count := 0
+	for _, f := range faculties {
+		count += len(f.Classes)
+	}
+	c.JSON(http.StatusOK, gin.H{"source": source, "count": count, "faculties": faculties})
+}
+
+type classMatch struct {
+	Class   string  `json:"class"`
+	Faculty string  `json:"faculty"`
+	Score   float64 `json:"score"`
+}
+
+// searchKey folds a class code or query to lower-case ASCII letters and
+// digits, so "Ctk 45", "ctk-45" and "CTK45" compare equal.
+func searchKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(dluparser.FoldDiacritics(s)) {
+		if r == 'đ' {
+			r = 'd'
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// editDistance is the optimal string alignment distance: insertions,
+// deletions, substitutions and swaps of adjacent characters each cost one.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// matchScore rates how well query matches a class code from 0 to 1: exact
+// matches first, then prefixes, substrings, and finally codes within a
+// typo or two of the query or of one of its prefixes.
+func matchScore(query, code string) float64 {
+	switch {
+	case query == code:
+		return 1
+	case strings.HasPrefix(code, query):
+		return 0.9
+	case strings.Contains(code, query):
+		return 0.8
+	}
+	allowed := max(1, len(query)/3)
+	d := editDistance(query, code)
+	if n := len([]rune(query)); n < len([]rune(code)) {
+		d = min(d, editDistance(query, string([]rune(code)[:n])))
+	}
+	if d > allowed {
+		return 0
+	}
+	return 0.7 * (1 - float64(d)/float64(len([]rune(query))+1))
+}
+
+const maxClassMatches = 50
+
+// classSearchHandler ranks the discovered classes against ?q=, tolerating
+// spacing, case, accents and small typos.
+func classSearchHandler(c *gin.Context) {
+	query := searchKey(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	limit := 10
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxClassMatches {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxClassMatches)})
+			return
+		}
+		limit = n
+	}
+	q, err := bindOptionalTerm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	faculties, _, err := discoverClasses(c.Request.Context(), q)
+	if errors.Is(err, errClassesNotConfigured) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+
+	matches := []classMatch{}
+	for _, f := range faculties {
+		for _, id := range f.Classes {
+			if score := matchScore(query, searchKey(id)); score > 0 {
+				matches = append(matches, classMatch{Class: id, Faculty: f.Faculty, Score: math.Round(score*100) / 100})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Class < matches[j].Class
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"query": c.Query("q"), "results": matches})
+}
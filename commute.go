@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// commuteDay is when a class has to be on campus on one day: from the start
+// of its first session to the end of its last.
+type commuteDay struct {
+	Weekday        string `json:"weekday"`
+	VietnameseName string `json:"vietnamese_name"`
+	Date           string `json:"date,omitempty"`
+	// Status is "classes", "no class", or "untimed" when the day has
+	// sessions but none whose periods are in the bell schedule.
+	Status       string `json:"status"`
+	Arrive       string `json:"arrive,omitempty"`
+	Leave        string `json:"leave,omitempty"`
+	ArriveAt     string `json:"arrive_at,omitempty"`
+	LeaveAt      string `json:"leave_at,omitempty"`
+	FirstSubject string `json:"first_subject,omitempty"`
+	LastSubject  string `json:"last_subject,omitempty"`
+}
+
+type commuteResponse struct {
+	Class string       `json:"class"`
+	Week  string       `json:"week"`
+	Days  []commuteDay `json:"days"`
+}
+
+// commuteDays lists Monday→Sunday with the first and last session of each
+// day, timed by the class's bell schedule. Sessions whose periods aren't in
+// it are ignored.
+func commuteDays(s dluparser.Schedule) []commuteDay {
+	times := periodTableFor(s.Class)
+	byIndex := daysByIndex(s)
+	days := make([]commuteDay, 0, 7)
+	for n := 1; n <= 7; n++ {
+		day := commuteDay{Weekday: time.Weekday(n % 7).String(), VietnameseName: dluparser.VietnameseDayNames[n], Status: "no class"}
+		var date time.Time
+		if !s.WeekStart.IsZero() {
+			y, m, dd := s.WeekStart.AddDate(0, 0, n-1).Date()
+			date = time.Date(y, m, dd, 0, 0, 0, 0, campusTZ)
+			day.Date = date.Format("2006-01-02")
+		}
+
+		d := byIndex[n]
+		first, last := clock(-1), clock(-1)
+		for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range slot {
+				day.Status = "untimed"
+				for _, run := range periodRuns(sub.Periods) {
+					start, end, ok := times.span(run[0], run[1])
+					if !ok {
+						continue
+					}
+					if first < 0 || start < first {
+						first, day.FirstSubject = start, sub.Name
+					}
+					if end > last {
+						last, day.LastSubject = end, sub.Name
+					}
+				}
+			}
+		}
+		if first >= 0 {
+			day.Status = "classes"
+			day.Arrive, day.Leave = first.String(), last.String()
+			if !date.IsZero() {
+				day.ArriveAt = date.Add(time.Duration(first) * time.Minute).Format(time.RFC3339)
+				day.LeaveAt = date.Add(time.Duration(last) * time.Minute).Format(time.RFC3339)
+			}
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// commuteHandler answers when to be on campus each day of a week and when
+// the day's classes are over.
+func commuteHandler(c *gin.Context) {
+	q, err := bindScheduleQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := loadSchedule(c.Request.Context(), q)
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, commuteResponse{Class: s.Class, Week: s.Week, Days: commuteDays(s)})
+}
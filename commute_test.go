@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCommute(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	rec := get(t, h, "/dlu/commute?"+weekParams+"&ClassStudentID=CTK45")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp commuteResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Days) != 7 {
+		t.Fatalf("got %d days, want 7", len(resp.Days))
+	}
+
+	tests := []struct {
+		day                   int
+		status, arrive, leave string
+		first                 string
+	}{
+		{0, "classes", "07:00", "09:25", "Lập trình Go"},
+		{1, "no class", "", "", ""},
+		{2, "classes", "13:50", "16:20", "Cơ sở dữ liệu"},
+		{6, "no class", "", "", ""},
+	}
+	for _, tt := range tests {
+		d := resp.Days[tt.day]
+		if d.Status != tt.status || d.Arrive != tt.arrive || d.Leave != tt.leave || d.FirstSubject != tt.first || d.LastSubject != tt.first {
+			t.Errorf("%s: got %+v, want status %q %s–%s first/last %q", d.VietnameseName, d, tt.status, tt.arrive, tt.leave, tt.first)
+		}
+		if tt.arrive == "" && (d.ArriveAt != "" || d.LeaveAt != "") {
+			t.Errorf("%s: free day has timestamps %q %q", d.VietnameseName, d.ArriveAt, d.LeaveAt)
+		}
+	}
+}
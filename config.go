@@ -0,0 +1,309 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/goccy/go-yaml"
+)
+
+// configErrors collects problems found while reading the environment so they
+// can all be reported at startup instead of silently falling back.
+var configErrors []error
+
+func configError(format string, args ...any) {
+	configErrors = append(configErrors, fmt.Errorf(format, args...))
+}
+
+// fileSettings is the optional YAML file named by --config or DLU_CONFIG.
+// Keys are setting names without the DLU_ prefix in lower case, e.g.
+// "read_timeout: 30s"; the environment takes precedence over the file and
+// flags over both.
+var fileSettings = loadConfigFile(configPath())
+
+// configPath finds --config before flag parsing, since settings are read
+// while the package initializes.
+func configPath() string {
+	args := os.Args[1:]
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv("DLU_CONFIG")
+}
+
+func loadConfigFile(path string) map[string]string {
+	if path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		configError("config file: %v", err)
+		return nil
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		configError("config file %s: %v", path, err)
+		return nil
+	}
+	settings := make(map[string]string, len(raw))
+	for k, v := range raw {
+		settings[strings.ToLower(k)] = fmt.Sprint(v)
+	}
+	return settings
+}
+
+// getenv reads a setting from the environment, falling back to the config
+// file.
+func getenv(name string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fileSettings[strings.ToLower(strings.TrimPrefix(name, "DLU_"))]
+}
+
+func envString(name, def string) string {
+	if v := getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(name string, def int) int {
+	raw := getenv(name)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		configError("%s=%q: expected a positive integer", name, raw)
+		return def
+	}
+	return n
+}
+
+func envFloat(name string, def float64) float64 {
+	raw := getenv(name)
+	if raw == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || f <= 0 {
+		configError("%s=%q: expected a positive number", name, raw)
+		return def
+	}
+	return f
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	raw := getenv(name)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		configError("%s=%q: expected a positive duration such as 30s", name, raw)
+		return def
+	}
+	return d
+}
+
+func envBool(name string, def bool) bool {
+	raw := getenv(name)
+	if raw == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		configError("%s=%q: expected true or false", name, raw)
+		return def
+	}
+	return b
+}
+
+var (
+	listenAddr = envString("DLU_LISTEN", ":8080")
+	logLevel   = envString("DLU_LOG_LEVEL", "info")
+)
+
+var (
+	readHeaderTimeout = envDuration("DLU_READ_HEADER_TIMEOUT", 5*time.Second)
+	readTimeout       = envDuration("DLU_READ_TIMEOUT", 15*time.Second)
+	writeTimeout      = envDuration("DLU_WRITE_TIMEOUT", 60*time.Second)
+	idleTimeout       = envDuration("DLU_IDLE_TIMEOUT", 120*time.Second)
+	shutdownGrace     = envDuration("DLU_SHUTDOWN_GRACE", 15*time.Second)
+)
+
+// registerFlags lets the command line override the most commonly tuned
+// settings; the defaults shown are what the environment and file resolved to.
+func registerFlags() {
+	flag.String("config", "", "YAML config file (also DLU_CONFIG)")
+	flag.StringVar(&listenAddr, "listen", listenAddr, "listen address")
+	flag.StringVar(&upstreamURL, "upstream", upstreamURL, "upstream timetable URL")
+	flag.DurationVar(&readHeaderTimeout, "read-header-timeout", readHeaderTimeout, "request header read timeout")
+	flag.DurationVar(&readTimeout, "read-timeout", readTimeout, "request read timeout")
+	flag.DurationVar(&writeTimeout, "write-timeout", writeTimeout, "response write timeout")
+	flag.DurationVar(&idleTimeout, "idle-timeout", idleTimeout, "keep-alive idle timeout")
+	flag.DurationVar(&shutdownGrace, "shutdown-grace", shutdownGrace, "how long to drain requests on SIGINT/SIGTERM")
+	flag.DurationVar(&cacheTTL, "cache-ttl", cacheTTL, "how long a fetched week is served from cache")
+	flag.DurationVar(&roomsCacheTTL, "rooms-cache-ttl", roomsCacheTTL, "how long /dlu/rooms reuses a crawl")
+	flag.StringVar(&logLevel, "log-level", logLevel, "debug, info, warn or error")
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// checkConfig validates the loaded configuration as a whole, logging a
+// summary with secrets redacted. Any error means the server must not start.
+func checkConfig() error {
+	errs := append([]error{}, configErrors...)
+
+	if u, err := url.Parse(upstreamURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("invalid upstream URL %q", upstreamURL))
+	}
+	if u, err := url.Parse(otlpEndpoint); otlpEndpoint != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		errs = append(errs, fmt.Errorf("DLU_OTLP_ENDPOINT: invalid URL %q, expected e.g. http://collector:4318", otlpEndpoint))
+	}
+
+	if _, err := parseLogLevel(logLevel); err != nil {
+		errs = append(errs, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", logLevel))
+	}
+	if logFormat != "json" && logFormat != "text" {
+		errs = append(errs, fmt.Errorf("invalid DLU_LOG_FORMAT %q, expected json or text", logFormat))
+	}
+
+	sel := loadSelectors("DLU_SELECTOR_")
+	if p, err := dluparser.NewHTMLParser(sel); err != nil {
+		errs = append(errs, err)
+	} else {
+		htmlParser = p
+	}
+	if p, err := loadProviders(); err != nil {
+		errs = append(errs, err)
+	} else {
+		providers = p
+	}
+
+	if botToken != "" && botSemester != "" {
+		if _, err := resolveSemester(botSemester); err != nil {
+			errs = append(errs, fmt.Errorf("DLU_BOT_SEMESTER: %w", err))
+		}
+	}
+
+	if historyDir != "" {
+		if h, err := openHistory(historyDir); err != nil {
+			errs = append(errs, fmt.Errorf("DLU_HISTORY_DIR: %w", err))
+		} else {
+			history = h
+		}
+	}
+
+	if webhookWorkers < 1 || webhookQueueSize < 1 {
+		errs = append(errs, fmt.Errorf("DLU_WEBHOOK_WORKERS (%d) and DLU_WEBHOOK_QUEUE (%d) must be at least 1", webhookWorkers, webhookQueueSize))
+	}
+	if subscriptionsFile != "" {
+		if subs, err := loadSubscriptions(subscriptionsFile); err != nil {
+			errs = append(errs, fmt.Errorf("DLU_SUBSCRIPTIONS_FILE: %w", err))
+		} else {
+			subscriptions = subs
+		}
+	}
+
+	if crawlSemester != "" {
+		if _, err := crawlTerm(); err != nil {
+			errs = append(errs, fmt.Errorf("DLU_CRAWL_SEMESTER/DLU_CRAWL_WEEKS: %w", err))
+		} else if x, err := openIndex(indexDir); err != nil {
+			errs = append(errs, fmt.Errorf("DLU_INDEX_DIR: %w", err))
+		} else {
+			crawlIndex = x
+		}
+	}
+
+	if fontPath != "" {
+		if f, err := loadTTF(fontPath); err != nil {
+			errs = append(errs, fmt.Errorf("DLU_FONT: %w", err))
+		} else {
+			renderFont = f
+		}
+	}
+
+	if _, ok := renderers[defaultFormat]; !ok {
+		errs = append(errs, fmt.Errorf("DLU_DEFAULT_FORMAT %q is not a known format, expected one of %s", defaultFormat, strings.Join(formatNames(), ", ")))
+	}
+
+	if apiKeysFile != "" {
+		if s, err := openKeyStore(apiKeysFile); err != nil {
+			errs = append(errs, fmt.Errorf("DLU_API_KEYS_FILE: %w", err))
+		} else {
+			apiKeys = s
+		}
+	} else if apiKeyRequired {
+		errs = append(errs, errors.New("DLU_API_KEY_REQUIRED needs DLU_API_KEYS_FILE"))
+	}
+
+	if upstreamTimeout >= writeTimeout {
+		errs = append(errs, fmt.Errorf("DLU_UPSTREAM_TIMEOUT (%s) must be shorter than DLU_WRITE_TIMEOUT (%s)", upstreamTimeout, writeTimeout))
+	}
+	if readHeaderTimeout > readTimeout {
+		errs = append(errs, fmt.Errorf("DLU_READ_HEADER_TIMEOUT (%s) exceeds DLU_READ_TIMEOUT (%s)", readHeaderTimeout, readTimeout))
+	}
+
+	log.Printf("config: listen=%s upstream=%s tls=%s log_level=%s", listenAddr, upstreamURL, tlsSummary(), logLevel)
+	log.Printf("config: timeouts read_header=%s read=%s write=%s idle=%s upstream=%s shutdown=%s", readHeaderTimeout, readTimeout, writeTimeout, idleTimeout, upstreamTimeout, shutdownGrace)
+	log.Printf("config: upstream concurrency=%d rate=%g/s", upstreamConcurrency, upstreamRate)
+	log.Printf("config: retries=%d backoff=%s max_backoff=%s", retryAttempts, retryBackoff, retryMaxBackoff)
+	log.Printf("config: breaker failures=%d cooldown=%s", breakerFailures, breakerCooldown)
+	log.Printf("config: limits body=%dB header=%dB rate=%g/s burst=%d", maxBodyBytes, maxHeaderBytes, rateLimit, rateBurst)
+	log.Printf("config: selectors header=%q rows=%q day=%q slot=%q templates=%s", sel.Header, sel.Rows, sel.Day, sel.Slot, strings.Join(templateNames, ","))
+	log.Printf("config: providers=%s", strings.Join(providerNames(), ","))
+	log.Printf("config: admin_key=%s bot_token=%s", redact(adminKey), redact(botToken))
+	log.Printf("config: api_keys_file=%q api_key_required=%t", apiKeysFile, apiKeyRequired)
+	log.Printf("config: class_groups=%s semesters=%d", groupSummary(), len(semesterTable))
+	log.Printf("config: cache ttl=%s stale_while_revalidate=%s stale_if_error=%s max_entries=%d", cacheTTL, cacheRevalidate, cacheStaleIfError, cacheMaxEntries)
+	log.Printf("config: rooms_cache_ttl=%s history_dir=%q font=%q default_format=%s", roomsCacheTTL, historyDir, fontPath, defaultFormat)
+	log.Printf("config: crawl_semester=%q crawl_weeks=%s crawl_interval=%s index_dir=%q", crawlSemester, strings.Join(crawlWeeks, ","), crawlInterval, indexDir)
+	log.Printf("config: otlp_endpoint=%q grpc_listen=%q", otlpEndpoint, grpcListen)
+	log.Printf("config: poll_interval=%s max_subscriptions=%d max_streams=%d webhook_workers=%d webhook_queue=%d", pollInterval, maxSubscriptions, maxStreams, webhookWorkers, webhookQueueSize)
+
+	return errors.Join(errs...)
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return "unset"
+	}
+	return "set"
+}
+
+func groupSummary() string {
+	names := make([]string, 0, len(classGroups))
+	for name, ids := range classGroups {
+		names = append(names, fmt.Sprintf("%s(%d)", name, len(ids)))
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
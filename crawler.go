@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// The crawler is enabled by DLU_CRAWL_SEMESTER. Every DLU_CRAWL_INTERVAL it
+// fetches DLU_CRAWL_WEEKS of that semester for every class discoverClasses
+// finds and keeps the result as an index, which room, teacher and search
+// endpoints answer from instead of crawling on demand. With DLU_INDEX_DIR
+// the index is also written to index.json there and survives restarts.
+var (
+	crawlSemester = getenv("DLU_CRAWL_SEMESTER")
+	crawlWeeks    = strings.Split(envString("DLU_CRAWL_WEEKS", "current,next"), ",")
+	crawlInterval = envDuration("DLU_CRAWL_INTERVAL", 6*time.Hour)
+	indexDir      = getenv("DLU_INDEX_DIR")
+)
+
+type indexedWeek struct {
+	Schedules map[string]dluparser.Schedule `json:"schedules"`
+	Errors    map[string]string             `json:"errors"`
+	WeekStart time.Time                     `json:"week_start"`
+	At        time.Time                     `json:"crawled_at"`
+}
+
+type scheduleIndex struct {
+	mu    sync.RWMutex
+	path  string
+	weeks map[string]*indexedWeek
+}
+
+var crawlIndex *scheduleIndex
+
+func indexKey(q scheduleQuery) string {
+	return q.YearStudy + "|" + q.TermID + "|" + q.Week
+}
+
+func openIndex(dir string) (*scheduleIndex, error) {
+	x := &scheduleIndex{weeks: map[string]*indexedWeek{}}
+	if dir == "" {
+		return x, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	x.path = filepath.Join(dir, "index.json")
+	b, err := os.ReadFile(x.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &x.weeks); err != nil {
+		return nil, err
+	}
+	// WeekStart isn't part of a schedule's JSON; restore it so days are dated.
+	for _, w := range x.weeks {
+		for id, s := range w.Schedules {
+			s.WeekStart = w.WeekStart
+			w.Schedules[id] = s
+		}
+	}
+	return x, nil
+}
+
+func (x *scheduleIndex) put(q scheduleQuery, w *indexedWeek) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.weeks[indexKey(q)] = w
+	if x.path == "" {
+		return
+	}
+	b, err := json.Marshal(x.weeks)
+	if err == nil {
+		tmp := x.path + ".tmp"
+		if err = os.WriteFile(tmp, b, 0o644); err == nil {
+			err = os.Rename(tmp, x.path)
+		}
+	}
+	if err != nil {
+		log.Printf("crawler: write index: %v", err)
+	}
+}
+
+// week returns the indexed crawl of q's week. The index may be nil, when the
+// crawler is off.
+func (x *scheduleIndex) week(q scheduleQuery) (*indexedWeek, bool) {
+	if x == nil {
+		return nil, false
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	w, ok := x.weeks[indexKey(q)]
+	return w, ok
+}
+
+// lookup serves a crawl of ids from the index, provided the indexed week
+// covers every one of them.
+func (x *scheduleIndex) lookup(q scheduleQuery, ids []string) (crawledWeek, bool) {
+	w, ok := x.week(q)
+	if !ok {
+		return crawledWeek{}, false
+	}
+	out := crawledWeek{Errors: map[string]string{}, At: w.At}
+	for _, id := range ids {
+		if s, ok := w.Schedules[id]; ok {
+			out.Schedules = append(out.Schedules, s)
+		} else if msg, ok := w.Errors[id]; ok {
+			out.Errors[id] = msg
+		} else {
+			return crawledWeek{}, false
+		}
+	}
+	return out, true
+}
+
+// crawlTerm resolves the crawler's semester and weeks, so a bad setting is
+// reported at startup.
+func crawlTerm() ([]scheduleQuery, error) {
+	sem, err := resolveSemester(crawlSemester)
+	if err != nil {
+		return nil, err
+	}
+	var qs []scheduleQuery
+	for _, week := range crawlWeeks {
+		q, err := scheduleQuery{YearStudy: sem.YearStudy, TermID: sem.TermID, Week: strings.TrimSpace(week)}.normalize()
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
+
+func runCrawler() {
+	for {
+		crawlOnce(context.Background())
+		time.Sleep(crawlInterval)
+	}
+}
+
+// crawlOnce indexes each configured week. Weeks are re-resolved every run so
+// "current" moves on as the term goes.
+func crawlOnce(ctx context.Context) {
+	qs, err := crawlTerm()
+	if err != nil {
+		log.Printf("crawler: %v", err)
+		return
+	}
+	for _, q := range qs {
+		ids, err := discoveredClassIDs(ctx, q)
+		if err != nil {
+			log.Printf("crawler: list classes: %v", err)
+			return
+		}
+		prev, _ := crawlIndex.week(q)
+		w := &indexedWeek{Schedules: map[string]dluparser.Schedule{}, Errors: map[string]string{}, At: time.Now()}
+		failed := 0
+		for id, res := range fetchClasses(ctx, q, ids) {
+			if res.Err != nil {
+				failed++
+				// Keep the last good copy rather than losing the class from
+				// the index over one failed fetch.
+				if prev != nil {
+					if s, ok := prev.Schedules[id]; ok {
+						w.Schedules[id] = s
+						w.WeekStart = prev.WeekStart
+						continue
+					}
+				}
+				w.Errors[id] = res.Err.Error()
+				continue
+			}
+			w.Schedules[id] = res.Schedule
+			w.WeekStart = res.Schedule.WeekStart
+		}
+		crawlIndex.put(q, w)
+		log.Printf("crawler: week %s of %s %s: %d classes, %d failed", q.Week, q.YearStudy, q.TermID, len(ids), failed)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+
+	"dlu-api/pkg/dluparser"
+)
+
+var csvHeader = []string{"day", "session", "subject", "code", "group", "section", "period", "room", "teacher"}
+
+// renderCSV writes one row per session, days in week order. The UTF-8 BOM
+// makes Excel read the Vietnamese text correctly; Google Sheets ignores it.
+func renderCSV(s dluparser.Schedule) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	w.Write(csvHeader)
+	for _, d := range s.OrderedDays() {
+		for i, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range slot {
+				w.Write([]string{d.VietnameseName, dluparser.Slots[i], sub.Name, sub.Code, sub.Group, sub.SectionLabel, sub.Period, sub.Room, sub.Teacher})
+			}
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
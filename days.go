@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+)
+
+func sameWeekday(a, b string) bool {
+	x, okA := dluparser.WeekdayIndex(a)
+	y, okB := dluparser.WeekdayIndex(b)
+	if okA && okB {
+		return x == y
+	}
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// filterDay keeps only the requested day. A recognized day with no entry in
+// the schedule yields an empty Days map rather than an error.
+func filterDay(s dluparser.Schedule, day string) (dluparser.Schedule, error) {
+	want, ok := dluparser.WeekdayIndex(day)
+	if !ok {
+		return s, fmt.Errorf("unrecognized day %q", day)
+	}
+	days := make(map[string]dluparser.DaySchedule)
+	for name, d := range s.Days {
+		if n, ok := dluparser.WeekdayIndex(name); ok && n == want {
+			days[name] = d
+		}
+	}
+	s.Days = days
+	return s, nil
+}
+
+// sessionNames maps accent-free session names in Vietnamese and English to
+// an index into dluparser.Slots.
+var sessionNames = map[string]int{
+	"sang": 0, "morning": 0,
+	"chieu": 1, "afternoon": 1,
+	"toi": 2, "evening": 2,
+}
+
+func sessionIndex(name string) (int, bool) {
+	n, ok := sessionNames[strings.ToLower(strings.TrimSpace(dluparser.FoldDiacritics(name)))]
+	return n, ok
+}
+
+// folded is s lower-cased without accents, for forgiving substring matches.
+func folded(s string) string {
+	return strings.ReplaceAll(strings.ToLower(dluparser.FoldDiacritics(s)), "đ", "d")
+}
+
+// filterSubjects keeps the subjects keep accepts, and only in the session
+// numbered session when that is 0 or more. Days stay even if left empty, as
+// with filterDay.
+func filterSubjects(s dluparser.Schedule, session int, keep func(dluparser.Subject) bool) dluparser.Schedule {
+	pick := func(i int, subjects []dluparser.Subject) []dluparser.Subject {
+		if session >= 0 && i != session {
+			return nil
+		}
+		var out []dluparser.Subject
+		for _, sub := range subjects {
+			if keep(sub) {
+				out = append(out, sub)
+			}
+		}
+		return out
+	}
+	days := make(map[string]dluparser.DaySchedule, len(s.Days))
+	for name, d := range s.Days {
+		days[name] = dluparser.DaySchedule{Sang: pick(0, d.Sang), Chieu: pick(1, d.Chieu), Toi: pick(2, d.Toi)}
+	}
+	s.Days = days
+	return s
+}
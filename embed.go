@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// embedPage is a standalone timetable for other sites to show in an iframe:
+// styles are inline and there is no script, so it works under strict
+// content security policies.
+var embedPage = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html lang="vi">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;padding:12px;font:14px/1.4 system-ui,-apple-system,"Segoe UI",Roboto,sans-serif;color:#212529;background:#fff}
+h1{font-size:18px;margin:0 0 2px}
+p.sub{margin:0 0 10px;color:#6c757d;font-size:13px}
+.wrap{overflow-x:auto}
+table{border-collapse:collapse;width:100%;min-width:560px;table-layout:fixed}
+th,td{border:1px solid #ced4da;padding:6px;vertical-align:top;text-align:left}
+thead th{background:#d9e1f2}
+th.day{width:90px;background:#f1f3f5;font-weight:600}
+th.day small{display:block;font-weight:normal;color:#6c757d}
+.sub-block{border-radius:4px;padding:4px 6px;margin-bottom:4px}
+.sub-block:last-child{margin-bottom:0}
+.sub-block b{display:block}
+.sub-block span{display:block;font-size:12px}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Subtitle}}<p class="sub">{{.}}</p>{{end}}
+<div class="wrap">
+<table>
+<thead><tr><th class="day">Thứ</th>{{range .Slots}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Days}}<tr><th class="day">{{.Name}}{{with .Date}}<small>{{.}}</small>{{end}}</th>{{range .Cells}}<td>{{range .}}<div class="sub-block" style="background:{{.Color}}"><b>{{.Name}}{{with .Code}} ({{.}}){{end}}</b>{{with .Room}}<span>Phòng: {{.}}</span>{{end}}{{with .Teacher}}<span>GV: {{.}}</span>{{end}}{{with .Period}}<span>Tiết: {{.}}</span>{{end}}</div>{{end}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+</div>
+</body>
+</html>
+`))
+
+type embedSubject struct {
+	dluparser.Subject
+	Color template.CSS
+}
+
+type embedDay struct {
+	Name  string
+	Date  string
+	Cells [][]embedSubject
+}
+
+func renderEmbed(s dluparser.Schedule) ([]byte, error) {
+	data := struct {
+		Title    string
+		Subtitle string
+		Slots    []string
+		Days     []embedDay
+	}{
+		Slots: dluparser.Slots,
+	}
+	data.Title, data.Subtitle = scheduleHeading(s)
+
+	days := daysByIndex(s)
+	for n := 1; n <= 7; n++ {
+		day := days[n]
+		row := embedDay{Name: dluparser.VietnameseDayNames[n]}
+		if day.Date != "" {
+			row.Date = shortDate(day.Date)
+		}
+		for _, subjects := range [][]dluparser.Subject{day.Sang, day.Chieu, day.Toi} {
+			var cell []embedSubject
+			for _, sub := range subjects {
+				c := subjectColor(sub)
+				cell = append(cell, embedSubject{sub, template.CSS(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))})
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		data.Days = append(data.Days, row)
+	}
+
+	var buf bytes.Buffer
+	if err := embedPage.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// langs are the ?lang= values; "vi" keeps the page's own field names.
+var langs = []string{"vi", "en"}
+
+// englishSessions names dluparser.Slots in English.
+var englishSessions = []string{"morning", "afternoon", "evening"}
+
+// EnglishSubject is Subject with English JSON keys, for lang=en.
+type EnglishSubject struct {
+	Subject   string  `json:"subject"`
+	Code      string  `json:"code,omitempty"`
+	Group     string  `json:"group"`
+	Class     string  `json:"class"`
+	Period    string  `json:"period"`
+	Room      string  `json:"room"`
+	Teacher   string  `json:"teacher"`
+	Lessons   string  `json:"lessons,omitempty"`
+	Done      int     `json:"lessons_done"`
+	Total     int     `json:"lessons_total"`
+	Remaining int     `json:"lessons_remaining"`
+	Progress  float64 `json:"progress"`
+	Section   string  `json:"section_label,omitempty"`
+
+	PeriodStart int    `json:"period_start,omitempty"`
+	PeriodEnd   int    `json:"period_end,omitempty"`
+	Periods     []int  `json:"periods,omitempty"`
+	StartTime   string `json:"start_time,omitempty"`
+	EndTime     string `json:"end_time,omitempty"`
+}
+
+type EnglishDay struct {
+	Date      string           `json:"date,omitempty"`
+	Morning   []EnglishSubject `json:"morning"`
+	Afternoon []EnglishSubject `json:"afternoon"`
+	Evening   []EnglishSubject `json:"evening"`
+}
+
+// EnglishWeek keys days by English weekday. It is a struct rather than a map
+// so the days come out Monday→Sunday.
+type EnglishWeek struct {
+	Monday    *EnglishDay `json:"monday,omitempty"`
+	Tuesday   *EnglishDay `json:"tuesday,omitempty"`
+	Wednesday *EnglishDay `json:"wednesday,omitempty"`
+	Thursday  *EnglishDay `json:"thursday,omitempty"`
+	Friday    *EnglishDay `json:"friday,omitempty"`
+	Saturday  *EnglishDay `json:"saturday,omitempty"`
+	Sunday    *EnglishDay `json:"sunday,omitempty"`
+}
+
+// EnglishConflict is dluparser.Conflict with English day and session names.
+type EnglishConflict struct {
+	Day      string           `json:"day"`
+	Session  string           `json:"session"`
+	Periods  []int            `json:"periods"`
+	Subjects []EnglishSubject `json:"subjects"`
+}
+
+type EnglishSchedule struct {
+	Class     string              `json:"class"`
+	Week      string              `json:"week"`
+	Days      EnglishWeek         `json:"days"`
+	Summary   string              `json:"summary,omitempty"`
+	Conflicts []EnglishConflict   `json:"conflicts,omitempty"`
+	Meta      *dluparser.Meta     `json:"meta,omitempty"`
+	Warnings  []dluparser.Warning `json:"warnings,omitempty"`
+}
+
+func englishSubjects(subjects []dluparser.Subject) []EnglishSubject {
+	if subjects == nil {
+		return nil
+	}
+	out := make([]EnglishSubject, len(subjects))
+	for i, s := range subjects {
+		out[i] = EnglishSubject{
+			Subject:     s.Name,
+			Code:        s.Code,
+			Group:       s.Group,
+			Class:       s.Class,
+			Period:      s.Period,
+			Room:        s.Room,
+			Teacher:     s.Teacher,
+			Lessons:     s.Lessons,
+			Done:        s.LessonsDone,
+			Total:       s.LessonsTotal,
+			Remaining:   s.LessonsRemaining,
+			Progress:    s.ProgressPercent,
+			Section:     s.SectionLabel,
+			PeriodStart: s.PeriodStart,
+			PeriodEnd:   s.PeriodEnd,
+			Periods:     s.Periods,
+			StartTime:   s.StartTime,
+			EndTime:     s.EndTime,
+		}
+	}
+	return out
+}
+
+// englishSchedule translates s for lang=en. Days the parser couldn't place
+// in the week have no English key and are left out.
+func englishSchedule(s dluparser.Schedule) EnglishSchedule {
+	out := EnglishSchedule{Class: s.Class, Week: s.Week, Summary: s.Summary, Meta: s.Meta, Warnings: s.Warnings}
+	week := []**EnglishDay{nil, &out.Days.Monday, &out.Days.Tuesday, &out.Days.Wednesday, &out.Days.Thursday, &out.Days.Friday, &out.Days.Saturday, &out.Days.Sunday}
+	for n, day := range daysByIndex(s) {
+		*week[n] = &EnglishDay{
+			Date:      day.Date,
+			Morning:   englishSubjects(day.Sang),
+			Afternoon: englishSubjects(day.Chieu),
+			Evening:   englishSubjects(day.Toi),
+		}
+	}
+	for _, c := range s.Conflicts {
+		ec := EnglishConflict{Day: c.Day, Session: c.Slot, Periods: c.Periods, Subjects: englishSubjects(c.Subjects)}
+		if n, ok := dluparser.WeekdayIndex(c.Day); ok {
+			ec.Day = strings.ToLower(time.Weekday(n % 7).String())
+		}
+		if i, ok := sessionIndex(c.Slot); ok {
+			ec.Session = englishSessions[i]
+		}
+		out.Conflicts = append(out.Conflicts, ec)
+	}
+	return out
+}
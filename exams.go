@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// examURL is the portal page listing a class's exams. The portal's exam view
+// isn't linked from the public schedule page, so it must be configured.
+var examURL = getenv("DLU_EXAM_URL")
+
+func examsHandler(c *gin.Context) {
+	if examURL == "" {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Exam schedule source is not configured"})
+		return
+	}
+
+	q, err := bindTermQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	v := url.Values{}
+	v.Set("YearStudy", q.YearStudy)
+	v.Set("TermID", q.TermID)
+	v.Set("ClassStudentID", q.ClassStudentID)
+	body, err := fetchURL(c.Request.Context(), examURL+"?"+v.Encode())
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+
+	exams, err := dluparser.ParseExamsHTML(bytes.NewReader(body))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"class": q.ClassStudentID,
+		"meta":  dluparser.Meta{YearStudy: q.YearStudy, TermID: q.TermID, Semester: q.Semester},
+		"exams": exams,
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// freeRun is a stretch of consecutive free periods within one session.
+type freeRun struct {
+	Session    string `json:"session"`
+	FromPeriod int    `json:"from_period"`
+	ToPeriod   int    `json:"to_period"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+}
+
+type freeDay struct {
+	Weekday        string    `json:"weekday"`
+	VietnameseName string    `json:"vietnamese_name"`
+	Date           string    `json:"date,omitempty"`
+	Free           []freeRun `json:"free"`
+}
+
+type freeSlotsResponse struct {
+	Class string    `json:"class"`
+	Week  string    `json:"week"`
+	Days  []freeDay `json:"days"`
+}
+
+// busyPeriods marks, per weekday, the periods with a class in any of the
+// schedules. A session whose periods couldn't be parsed blocks its whole
+// slot, since there is no telling which part of it is taken.
+func busyPeriods(schedules []dluparser.Schedule) map[int]map[int]bool {
+	busy := make(map[int]map[int]bool)
+	for _, s := range schedules {
+		for name, d := range s.Days {
+			n, ok := dluparser.WeekdayIndex(name)
+			if !ok {
+				continue
+			}
+			if busy[n] == nil {
+				busy[n] = make(map[int]bool)
+			}
+			for i, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+				for _, sub := range slot {
+					periods := sub.Periods
+					if len(periods) == 0 {
+						for p := range periodTimes {
+							if periodSession(p) == i {
+								periods = append(periods, p)
+							}
+						}
+					}
+					for _, p := range periods {
+						busy[n][p] = true
+					}
+				}
+			}
+		}
+	}
+	return busy
+}
+
+// freeSlots lists the periods of the bell schedule that none of the
+// schedules use, Monday→Sunday, as runs that never cross a session break.
+// weekStart dates the days when it is set.
+func freeSlots(schedules []dluparser.Schedule, weekStart time.Time) []freeDay {
+	periods := make([]int, 0, len(periodTimes))
+	for p := range periodTimes {
+		periods = append(periods, p)
+	}
+	sort.Ints(periods)
+
+	busy := busyPeriods(schedules)
+	days := make([]freeDay, 0, 7)
+	for n := 1; n <= 7; n++ {
+		day := freeDay{Weekday: time.Weekday(n % 7).String(), VietnameseName: dluparser.VietnameseDayNames[n], Free: []freeRun{}}
+		if !weekStart.IsZero() {
+			day.Date = weekStart.AddDate(0, 0, n-1).Format("2006-01-02")
+		}
+		var free []int
+		for _, p := range periods {
+			if !busy[n][p] {
+				free = append(free, p)
+			}
+		}
+		for _, run := range periodRuns(free) {
+			for from := run[0]; from <= run[1]; {
+				to := from
+				for to < run[1] && periodSession(to+1) == periodSession(from) {
+					to++
+				}
+				start, end, _ := sessionSpan(from, to)
+				day.Free = append(day.Free, freeRun{dluparser.Slots[periodSession(from)], from, to, start.String(), end.String()})
+				from = to + 1
+			}
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// freeSlotsHandler lists the periods in a week when a class has nothing
+// scheduled, for finding meeting times.
+func freeSlotsHandler(c *gin.Context) {
+	q, err := bindScheduleQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := loadSchedule(c.Request.Context(), q)
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, freeSlotsResponse{
+		Class: s.Class,
+		Week:  s.Week,
+		Days:  freeSlots([]dluparser.Schedule{s}, s.WeekStart),
+	})
+}
+
+type commonFreeResponse struct {
+	Classes []string  `json:"classes"`
+	Week    string    `json:"week"`
+	Days    []freeDay `json:"days"`
+}
+
+// commonFreeSlotsHandler intersects the free periods of several classes,
+// given as a comma-separated ClassStudentID. Every class has to load: a
+// missing timetable would make its busy periods look free.
+func commonFreeSlotsHandler(c *gin.Context) {
+	q, err := bindWeekQuery(c)
+	var ids []string
+	if err == nil {
+		ids, err = normalizeClassIDs(strings.Split(c.Query("ClassStudentID"), ","))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ids) > maxBatchClasses {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d classes at once", maxBatchClasses)})
+		return
+	}
+
+	var schedules []dluparser.Schedule
+	errs := map[string]string{}
+	for id, res := range fetchClasses(c.Request.Context(), q, ids) {
+		if res.Err != nil {
+			errs[id] = res.Err.Error()
+			continue
+		}
+		schedules = append(schedules, res.Schedule)
+	}
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Some classes could not be fetched", "errors": errs})
+		return
+	}
+
+	var weekStart time.Time
+	if len(schedules) > 0 {
+		weekStart = schedules[0].WeekStart
+	}
+	c.JSON(http.StatusOK, commonFreeResponse{Classes: ids, Week: q.Week, Days: freeSlots(schedules, weekStart)})
+}
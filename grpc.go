@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"dlu-api/pkg/dlupb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcListen is the address of the gRPC ScheduleService, e.g. ":9090". The
+// service is off without it. It answers from the same cache, limits and
+// breaker as the HTTP API.
+var grpcListen = getenv("DLU_GRPC_LISTEN")
+
+type scheduleService struct {
+	dlupb.UnimplementedScheduleServiceServer
+}
+
+// newGRPCServer builds the gRPC server, checking API keys the way the HTTP
+// API does, from x-api-key metadata.
+func newGRPCServer() *grpc.Server {
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+			if err := grpcAuth(ctx); err != nil {
+				return nil, err
+			}
+			return h(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+			if err := grpcAuth(ss.Context()); err != nil {
+				return err
+			}
+			return h(srv, ss)
+		}),
+	)
+	dlupb.RegisterScheduleServiceServer(s, scheduleService{})
+	return s
+}
+
+// serveGRPC listens on DLU_GRPC_LISTEN, if set, and returns the server so
+// it can be stopped on shutdown.
+func serveGRPC() (*grpc.Server, error) {
+	if grpcListen == "" {
+		return nil, nil
+	}
+	lis, err := net.Listen("tcp", grpcListen)
+	if err != nil {
+		return nil, err
+	}
+	s := newGRPCServer()
+	go s.Serve(lis)
+	return s, nil
+}
+
+// stopGRPC lets in-flight calls finish until ctx runs out, then cuts them
+// off. Streams end on their own once closeStreams has run.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+func grpcAuth(ctx context.Context) error {
+	if apiKeys == nil {
+		return nil
+	}
+	var raw string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-api-key"); len(v) > 0 {
+			raw = v[0]
+		}
+	}
+	if raw == "" {
+		if apiKeyRequired {
+			return status.Error(codes.Unauthenticated, "API key required")
+		}
+		return nil
+	}
+	key, ok, wait := apiKeys.use(raw)
+	if key == nil {
+		return status.Error(codes.Unauthenticated, "Invalid API key")
+	}
+	if !ok {
+		return status.Errorf(codes.ResourceExhausted, "Too many requests, retry in %s", wait.Round(time.Second))
+	}
+	return nil
+}
+
+// grpcError is upstreamError's gRPC counterpart.
+func grpcError(err error) error {
+	var open *openError
+	var se *statusError
+	switch {
+	case errors.As(err, &open):
+		return status.Errorf(codes.Unavailable, "%v, retry in %s", err, open.retryAfter.Round(time.Second))
+	case errors.As(err, &se) && se.Code == http.StatusTooManyRequests:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, errUpstreamTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// protoWeekQuery reads a WeekQuery as a scheduleQuery without a class.
+func protoWeekQuery(w *dlupb.WeekQuery) scheduleQuery {
+	return scheduleQuery{YearStudy: w.GetYearStudy(), TermID: w.GetTermId(), Week: w.GetWeek(), Semester: w.GetSemester()}
+}
+
+func (scheduleService) GetSchedule(ctx context.Context, req *dlupb.GetScheduleRequest) (*dlupb.Schedule, error) {
+	q := protoWeekQuery(req.GetWeek())
+	q.ClassStudentID = req.GetClassStudentId()
+	if q.ClassStudentID == "" {
+		return nil, status.Error(codes.InvalidArgument, errMissingParams.Error())
+	}
+	q, err := q.resolve()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	s, err := loadSchedule(ctx, q)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return scheduleProto(s), nil
+}
+
+func (scheduleService) GetBatch(ctx context.Context, req *dlupb.GetBatchRequest) (*dlupb.GetBatchResponse, error) {
+	q, err := protoWeekQuery(req.GetWeek()).resolve()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ids, err := normalizeClassIDs(req.GetClassStudentIds())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(ids) > maxBatchClasses {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d classes per batch", maxBatchClasses)
+	}
+	resp := &dlupb.GetBatchResponse{Schedules: map[string]*dlupb.Schedule{}, Errors: map[string]string{}}
+	for id, res := range fetchClasses(ctx, q, ids) {
+		if res.Err != nil {
+			resp.Errors[id] = res.Err.Error()
+			continue
+		}
+		resp.Schedules[id] = scheduleProto(res.Schedule)
+	}
+	return resp, nil
+}
+
+// StreamChanges watches each class like /dlu/stream, all on one stream.
+func (scheduleService) StreamChanges(req *dlupb.StreamChangesRequest, stream grpc.ServerStreamingServer[dlupb.ScheduleChange]) error {
+	ctx := stream.Context()
+	base := protoWeekQuery(req.GetWeek())
+	follow := strings.EqualFold(strings.TrimSpace(base.Week), "current")
+	base, err := base.resolve()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	ids, err := normalizeClassIDs(req.GetClassStudentIds())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(ids) > maxBatchClasses {
+		return status.Errorf(codes.InvalidArgument, "at most %d classes per stream", maxBatchClasses)
+	}
+
+	events := make(chan changeEvent, streamBuffer*len(ids))
+	var ready []*dlupb.ScheduleChange
+	for id, res := range fetchClasses(ctx, base, ids) {
+		if res.Err != nil {
+			return grpcError(fmt.Errorf("%s: %w", id, res.Err))
+		}
+		q := base
+		q.ClassStudentID = id
+		w := &watcher{id: id, query: q, last: res.Schedule, events: events, follow: follow}
+		if !addWatcher(w) {
+			return status.Error(codes.ResourceExhausted, "Stream limit reached")
+		}
+		defer removeWatcher(w)
+		ready = append(ready, changeProto(changeEvent{
+			Event: "ready", YearStudy: q.YearStudy, TermID: q.TermID, Week: q.Week, ClassStudentID: id,
+			Checksum: res.Schedule.Meta.Checksum,
+		}))
+	}
+	for _, ev := range ready {
+		if err := stream.Send(ev); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-streamsClosing:
+			return nil
+		case ev := <-events:
+			if err := stream.Send(changeProto(ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func subjectProto(s dluparser.Subject) *dlupb.Subject {
+	periods := make([]int32, len(s.Periods))
+	for i, p := range s.Periods {
+		periods[i] = int32(p)
+	}
+	return &dlupb.Subject{
+		Name: s.Name, Code: s.Code, Group: s.Group, Class: s.Class, Period: s.Period,
+		Room: s.Room, Teacher: s.Teacher, Lessons: s.Lessons,
+		LessonsDone: int32(s.LessonsDone), LessonsTotal: int32(s.LessonsTotal),
+		LessonsRemaining: int32(s.LessonsRemaining), ProgressPercent: s.ProgressPercent,
+		SectionLabel: s.SectionLabel,
+		PeriodStart:  int32(s.PeriodStart), PeriodEnd: int32(s.PeriodEnd), Periods: periods,
+		StartTime: s.StartTime, EndTime: s.EndTime,
+	}
+}
+
+func subjectsProto(subjects []dluparser.Subject) []*dlupb.Subject {
+	out := make([]*dlupb.Subject, len(subjects))
+	for i, s := range subjects {
+		out[i] = subjectProto(s)
+	}
+	return out
+}
+
+func scheduleProto(s dluparser.Schedule) *dlupb.Schedule {
+	out := &dlupb.Schedule{Class: s.Class, Week: s.Week}
+	for _, d := range s.OrderedDays() {
+		out.Days = append(out.Days, &dlupb.Day{
+			Weekday: d.Weekday, VietnameseName: d.VietnameseName, Date: d.Date,
+			Morning: subjectsProto(d.Sang), Afternoon: subjectsProto(d.Chieu), Evening: subjectsProto(d.Toi),
+		})
+	}
+	if m := s.Meta; m != nil {
+		out.Meta = &dlupb.Meta{
+			YearStudy: m.YearStudy, TermId: m.TermID, Semester: m.Semester, Checksum: m.Checksum,
+			Provider: m.Provider, Template: m.Template, Attempts: int32(m.Attempts),
+			FetchedAt: m.FetchedAt, Stale: m.Stale, Cache: m.Cache,
+		}
+	}
+	for _, w := range s.Warnings {
+		out.Warnings = append(out.Warnings, &dlupb.Warning{Code: w.Code, Message: w.Message})
+	}
+	return out
+}
+
+func changeProto(ev changeEvent) *dlupb.ScheduleChange {
+	out := &dlupb.ScheduleChange{
+		Event: ev.Event, ClassStudentId: ev.ClassStudentID,
+		YearStudy: ev.YearStudy, TermId: ev.TermID, Week: ev.Week, Checksum: ev.Checksum,
+	}
+	for _, c := range ev.Changes {
+		pc := &dlupb.Change{Type: c.Type, Day: c.Day, Slot: c.Slot, Fields: c.Fields}
+		if c.Before != nil {
+			pc.Before = subjectProto(*c.Before)
+		}
+		if c.After != nil {
+			pc.After = subjectProto(*c.After)
+		}
+		out.Changes = append(out.Changes, pc)
+	}
+	if ev.Schedule != nil {
+		out.Schedule = scheduleProto(*ev.Schedule)
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"dlu-api/pkg/dlupb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPC serves the ScheduleService in memory in front of a fake portal.
+func newTestGRPC(t *testing.T, page string) dlupb.ScheduleServiceClient {
+	t.Helper()
+	newTestServer(t, servePage(t, page))
+	lis := bufconn.Listen(1 << 20)
+	s := newGRPCServer()
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return dlupb.NewScheduleServiceClient(conn)
+}
+
+var testWeek = &dlupb.WeekQuery{YearStudy: "2024-2025", TermId: "HK01", Week: "3"}
+
+func TestGRPCGetSchedule(t *testing.T) {
+	client := newTestGRPC(t, "mau2.html")
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		req   *dlupb.GetScheduleRequest
+		code  codes.Code
+		first string
+	}{
+		{"schedule", &dlupb.GetScheduleRequest{Week: testWeek, ClassStudentId: "ctk45"}, codes.OK, "Lập trình Go"},
+		{"no class", &dlupb.GetScheduleRequest{Week: testWeek}, codes.InvalidArgument, ""},
+		{"bad week", &dlupb.GetScheduleRequest{Week: &dlupb.WeekQuery{YearStudy: "2024", TermId: "HK01", Week: "3"}, ClassStudentId: "CTK45"}, codes.InvalidArgument, ""},
+	}
+	for _, tt := range tests {
+		s, err := client.GetSchedule(ctx, tt.req)
+		if status.Code(err) != tt.code {
+			t.Errorf("%s: %v, want %s", tt.name, err, tt.code)
+			continue
+		}
+		if tt.code != codes.OK {
+			continue
+		}
+		if s.GetClass() != "CTK45" || len(s.GetDays()) != 2 || s.GetMeta().GetChecksum() == "" {
+			t.Fatalf("%s: got %v", tt.name, s)
+		}
+		mon := s.GetDays()[0]
+		if mon.GetWeekday() != "Monday" || len(mon.GetMorning()) != 1 {
+			t.Fatalf("%s: first day %v", tt.name, mon)
+		}
+		sub := mon.GetMorning()[0]
+		if sub.GetName() != tt.first || sub.GetStartTime() != "07:00" || len(sub.GetPeriods()) != 3 || sub.GetSectionLabel() != "INF123 - Nhóm 1" {
+			t.Errorf("%s: first subject %v", tt.name, sub)
+		}
+	}
+}
+
+func TestGRPCGetBatch(t *testing.T) {
+	client := newTestGRPC(t, "mau2.html")
+	resp, err := client.GetBatch(context.Background(), &dlupb.GetBatchRequest{Week: testWeek, ClassStudentIds: []string{"CTK45", "ctk46"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetSchedules()) != 2 || len(resp.GetErrors()) != 0 {
+		t.Fatalf("got %d schedules, errors %v", len(resp.GetSchedules()), resp.GetErrors())
+	}
+	if _, ok := resp.GetSchedules()["CTK46"]; !ok {
+		t.Errorf("class codes not normalized: %v", resp.GetSchedules())
+	}
+
+	_, err = client.GetBatch(context.Background(), &dlupb.GetBatchRequest{Week: testWeek, ClassStudentIds: make([]string, maxBatchClasses+1)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("oversized batch: %v", err)
+	}
+}
+
+func TestGRPCStreamChanges(t *testing.T) {
+	client := newTestGRPC(t, "mau2.html")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamChanges(ctx, &dlupb.StreamChangesRequest{Week: testWeek, ClassStudentIds: []string{"CTK45"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ready.GetEvent() != "ready" || ready.GetClassStudentId() != "CTK45" || ready.GetChecksum() == "" {
+		t.Fatalf("first message %v", ready)
+	}
+
+	// Stand in for the poller finding the room changed.
+	var w *watcher
+	for q, ws := range watchersByQuery() {
+		if q.ClassStudentID == "CTK45" {
+			w = ws[0]
+		}
+	}
+	if w == nil {
+		t.Fatal("stream registered no watcher")
+	}
+	changed := w.last
+	changed.Days = map[string]dluparser.DaySchedule{}
+	for name, d := range w.last.Days {
+		d.Sang = append([]dluparser.Subject(nil), d.Sang...)
+		for i := range d.Sang {
+			d.Sang[i].Room = "C3.303"
+		}
+		changed.Days[name] = d
+	}
+	w.update(changed)
+
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.GetEvent() != "schedule.changed" || len(ev.GetChanges()) != 1 {
+		t.Fatalf("change event %v", ev)
+	}
+	if c := ev.GetChanges()[0]; c.GetBefore().GetRoom() != "A1.101" || c.GetAfter().GetRoom() != "C3.303" {
+		t.Errorf("change %v", c)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for len(watchersByQuery()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(watchersByQuery()); n != 0 {
+		t.Errorf("%d watchers left after the stream closed", n)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+func scheduleHandler(c *gin.Context) {
+	serveSchedule(c, "")
+}
+
+// formatHandler serves /dlu in a fixed format, for endpoints such as /dlu/ics
+// that calendar apps subscribe to without extra parameters.
+func formatHandler(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serveSchedule(c, format)
+	}
+}
+
+func serveSchedule(c *gin.Context, format string) {
+	q, err := bindScheduleQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts, err := bindViewOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format != "" {
+		opts.Format, opts.Negotiated = format, false
+	}
+
+	schedule, err := loadSchedule(c.Request.Context(), q)
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+	writeSchedule(c, schedule, opts)
+}
+
+// debugHTMLHandler returns the upstream page untouched so maintainers can
+// capture fixtures when the layout changes.
+func debugHTMLHandler(c *gin.Context) {
+	q, err := bindScheduleQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, _, err := fetchHTML(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+}
+
+var adminKey = getenv("DLU_ADMIN_KEY")
+
+// requireAdmin gates maintenance endpoints behind DLU_ADMIN_KEY; they stay
+// disabled when no key is configured.
+func requireAdmin(c *gin.Context) {
+	if adminKey == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	key := c.GetHeader("X-Admin-Key")
+	if subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin key"})
+		return
+	}
+	c.Next()
+}
+
+// findRoomHandler reports free and occupied rooms at a day and period across
+// every configured class, or a single group when ?group= is given. It serves
+// both /dlu/find-room and /dlu/rooms.
+func findRoomHandler(c *gin.Context) {
+	q, err := bindWeekQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group := c.Query("group")
+	ids := allClasses()
+	if group != "" {
+		var ok bool
+		if ids, ok = classGroups[group]; !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown class group"})
+			return
+		}
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "No classes configured in DLU_CLASS_GROUPS"})
+		return
+	}
+
+	// Day and Period are the documented names; day and period are accepted
+	// as aliases.
+	day := queryAny(c, "Day", "day")
+	period, err := strconv.Atoi(queryAny(c, "Period", "period"))
+	if day == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Day and numeric Period are required"})
+		return
+	}
+
+	w := crawlWeek(c.Request.Context(), q, ids)
+	occ := roomsAt(w.Schedules, day, period)
+	resp := gin.H{
+		"day":        day,
+		"period":     period,
+		"free":       occ.Free,
+		"occupied":   occ.Occupied,
+		"classes":    len(ids),
+		"crawled_at": w.At.UTC().Format(time.RFC3339),
+		"errors":     w.Errors,
+	}
+	if group != "" {
+		resp["group"] = group
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+const maxBulkItems = 500
+
+type bulkParseResult struct {
+	Schedule *dluparser.Schedule `json:"schedule,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// bulkParseHandler re-runs the parser over captured timetable texts, which is
+// how parser changes get checked against a corpus of samples.
+func bulkParseHandler(c *gin.Context) {
+	var inputs []string
+	if !bindJSON(c, &inputs) {
+		return
+	}
+	if len(inputs) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d timetables per request", maxBulkItems)})
+		return
+	}
+
+	results := make([]bulkParseResult, len(inputs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, aggregateWorkers)
+	for i, input := range inputs {
+		wg.Add(1)
+		go func(i int, input string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if strings.TrimSpace(input) == "" {
+				results[i].Error = "empty timetable"
+				return
+			}
+			s := dluparser.ParseSchedule(input)
+			if len(s.Days) == 0 {
+				results[i].Error = "no day headers found"
+				return
+			}
+			stampTimes(&s)
+			results[i].Schedule = &s
+		}(i, input)
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, results)
+}
+
+// validateHandler runs the same parameter checks as /dlu without touching the
+// upstream, so clients can vet user input before a slow scrape.
+func validateHandler(c *gin.Context) {
+	q, err := bindScheduleQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"valid": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"valid":          true,
+		"YearStudy":      q.YearStudy,
+		"TermID":         q.TermID,
+		"Week":           q.Week,
+		"ClassStudentID": q.ClassStudentID,
+	})
+}
+
+// facultyHandler returns a week for every class in a configured group along
+// with room and teacher utilization, for administrators.
+func facultyHandler(c *gin.Context) {
+	q, err := bindWeekQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group := c.Query("group")
+	classIDs, ok := classGroups[group]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown class group"})
+		return
+	}
+
+	schedules := make(map[string]dluparser.Schedule)
+	errs := make(map[string]string)
+	for id, res := range fetchClasses(c.Request.Context(), q, classIDs) {
+		if res.Err != nil {
+			errs[id] = res.Err.Error()
+			continue
+		}
+		schedules[id] = res.Schedule
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":       group,
+		"schedules":   schedules,
+		"utilization": computeUtilization(schedules),
+		"errors":      errs,
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestFindRoom(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	prevGroups, prevCrawls := classGroups, crawlCache
+	t.Cleanup(func() { classGroups, crawlCache = prevGroups, prevCrawls })
+	classGroups = map[string][]string{"A": {"CTK45"}}
+	crawlCache = map[string]crawledWeek{}
+
+	tests := []struct {
+		path     string
+		params   url.Values
+		want     int
+		occupied []string
+	}{
+		{"/dlu/rooms", url.Values{"Day": {"Thứ 2"}, "Period": {"2"}}, http.StatusOK, []string{"A1.101"}},
+		{"/dlu/rooms", url.Values{"day": {"Thứ 2"}, "period": {"2"}}, http.StatusOK, []string{"A1.101"}},
+		{"/dlu/rooms", url.Values{"Day": {"Thứ 4"}, "Period": {"8"}, "group": {"A"}}, http.StatusOK, []string{"B2.202"}},
+		{"/dlu/find-room", url.Values{"Day": {"Thứ 2"}, "Period": {"5"}}, http.StatusOK, []string{}},
+		{"/dlu/find-room", url.Values{"day": {"Thứ 2"}, "period": {"5"}}, http.StatusOK, []string{}},
+		{"/dlu/rooms", url.Values{"Day": {"Thứ 2"}}, http.StatusBadRequest, nil},
+		{"/dlu/rooms", url.Values{"Day": {"Thứ 2"}, "Period": {"x"}}, http.StatusBadRequest, nil},
+		{"/dlu/rooms", url.Values{"Day": {"Thứ 2"}, "Period": {"2"}, "group": {"B"}}, http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		target := tt.path + "?" + weekParams + "&" + tt.params.Encode()
+		rec := get(t, h, target)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", target, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			Free     []string `json:"free"`
+			Occupied []string `json:"occupied"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(resp.Occupied, tt.occupied) {
+			t.Errorf("%s: occupied = %v, want %v", target, resp.Occupied, tt.occupied)
+		}
+		if len(resp.Free)+len(resp.Occupied) != 2 {
+			t.Errorf("%s: free %v and occupied %v don't cover both rooms", target, resp.Free, resp.Occupied)
+		}
+	}
+}
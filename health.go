@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readyProbe makes /readyz check that the portal answers. Probe results are
+// reused for readyProbeCacheFor so frequent health checks don't turn into
+// upstream traffic.
+var readyProbe = envBool("DLU_READY_PROBE_UPSTREAM", false)
+
+const readyProbeCacheFor = 10 * time.Second
+
+type probeResult struct {
+	Reachable bool      `json:"reachable"`
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var (
+	probeMu   sync.Mutex
+	lastProbe probeResult
+)
+
+func probeUpstream(ctx context.Context) probeResult {
+	probeMu.Lock()
+	defer probeMu.Unlock()
+	if time.Since(lastProbe.CheckedAt) < readyProbeCacheFor {
+		return lastProbe
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	res := probeResult{CheckedAt: start.UTC()}
+
+	var req *http.Request
+	release, err := upstreamLimiter.acquire(ctx)
+	if err == nil {
+		defer release()
+		req, err = http.NewRequestWithContext(ctx, http.MethodHead, upstreamURL, nil)
+	}
+	if err == nil {
+		var resp *http.Response
+		if resp, err = httpClient.Do(req); err == nil {
+			resp.Body.Close()
+			res.Reachable = resp.StatusCode < 500
+			if !res.Reachable {
+				res.Error = resp.Status
+			}
+		}
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	res.LatencyMS = time.Since(start).Milliseconds()
+	lastProbe = res
+	return res
+}
+
+func healthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyzHandler reports whether the server should receive traffic. Without
+// DLU_READY_PROBE_UPSTREAM it only reflects the circuit breaker, which
+// already tracks whether recent fetches succeeded.
+func readyzHandler(c *gin.Context) {
+	resp := gin.H{"status": "ok", "breaker": upstreamBreaker.status().String()}
+	status := http.StatusOK
+	if readyProbe {
+		probe := probeUpstream(c.Request.Context())
+		resp["upstream"] = probe
+		if !probe.Reachable {
+			resp["status"] = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(status, resp)
+}
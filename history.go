@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// historyDir enables snapshot history. Snapshots are appended to
+// history.jsonl there, one JSON object per line; only an index (and not the
+// schedules themselves) is kept in memory.
+var historyDir = getenv("DLU_HISTORY_DIR")
+
+type snapshotInfo struct {
+	ID             int64     `json:"id"`
+	YearStudy      string    `json:"YearStudy"`
+	TermID         string    `json:"TermID"`
+	Week           string    `json:"Week"`
+	ClassStudentID string    `json:"ClassStudentID"`
+	Checksum       string    `json:"checksum"`
+	FetchedAt      time.Time `json:"fetched_at"`
+}
+
+type snapshotRecord struct {
+	snapshotInfo
+	Schedule dluparser.Schedule `json:"schedule"`
+}
+
+type historyStore struct {
+	mu     sync.Mutex
+	file   *os.File
+	index  []snapshotInfo
+	offset map[int64]int64
+	// latest is the checksum last stored per class and week, so refetching an
+	// unchanged page doesn't add a snapshot.
+	latest map[scheduleQuery]string
+}
+
+var history *historyStore
+
+func openHistory(dir string) (*historyStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "history.jsonl"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	h := &historyStore{file: f, offset: map[int64]int64{}, latest: map[scheduleQuery]string{}}
+
+	r := bufio.NewReader(f)
+	var pos int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && err == nil {
+			var rec snapshotInfo
+			if json.Unmarshal(line, &rec) == nil {
+				h.add(rec, pos)
+			}
+		}
+		pos += int64(len(line))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func (h *historyStore) add(info snapshotInfo, pos int64) {
+	h.index = append(h.index, info)
+	h.offset[info.ID] = pos
+	h.latest[info.query()] = info.Checksum
+}
+
+func (i snapshotInfo) query() scheduleQuery {
+	return scheduleQuery{YearStudy: i.YearStudy, TermID: i.TermID, Week: i.Week, ClassStudentID: i.ClassStudentID}
+}
+
+// record appends a snapshot unless it matches the latest one stored for the
+// same class and week.
+func (h *historyStore) record(q scheduleQuery, s dluparser.Schedule) error {
+	info := snapshotInfo{
+		YearStudy:      q.YearStudy,
+		TermID:         q.TermID,
+		Week:           q.Week,
+		ClassStudentID: q.ClassStudentID,
+		Checksum:       s.Meta.Checksum,
+		FetchedAt:      time.Now().UTC(),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.latest[info.query()] == info.Checksum {
+		return nil
+	}
+	info.ID = 1
+	if n := len(h.index); n > 0 {
+		info.ID = h.index[n-1].ID + 1
+	}
+
+	line, err := json.Marshal(snapshotRecord{info, s})
+	if err != nil {
+		return err
+	}
+	pos, err := h.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := h.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	h.add(info, pos)
+	return nil
+}
+
+// close flushes and closes the history file; later records fail.
+func (h *historyStore) close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.file.Sync(); err != nil {
+		h.file.Close()
+		return err
+	}
+	return h.file.Close()
+}
+
+func (h *historyStore) list(q scheduleQuery) []snapshotInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := []snapshotInfo{}
+	for _, info := range h.index {
+		if info.query() == q {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+func (h *historyStore) load(id int64) (snapshotRecord, error) {
+	h.mu.Lock()
+	pos, ok := h.offset[id]
+	h.mu.Unlock()
+	if !ok {
+		return snapshotRecord{}, fmt.Errorf("unknown snapshot %d", id)
+	}
+
+	line, err := bufio.NewReader(io.NewSectionReader(h.file, pos, 1<<62)).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return snapshotRecord{}, err
+	}
+	var rec snapshotRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return snapshotRecord{}, err
+	}
+	return rec, nil
+}
+
+func recordSnapshot(q scheduleQuery, s dluparser.Schedule) {
+	if history == nil {
+		return
+	}
+	if err := history.record(q, s); err != nil {
+		log.Printf("history: %v", err)
+	}
+}
+
+func historyHandler(c *gin.Context) {
+	if history == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "History is not enabled"})
+		return
+	}
+	q, err := bindScheduleQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q.Semester = ""
+	c.JSON(http.StatusOK, gin.H{"snapshots": history.list(q)})
+}
+
+func diffHandler(c *gin.Context) {
+	if history == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "History is not enabled"})
+		return
+	}
+	from, errFrom := strconv.ParseInt(c.Query("from"), 10, 64)
+	to, errTo := strconv.ParseInt(c.Query("to"), 10, 64)
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be snapshot ids"})
+		return
+	}
+
+	a, err := history.load(from)
+	if err == nil {
+		var b snapshotRecord
+		if b, err = history.load(to); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"from":    a.snapshotInfo,
+				"to":      b.snapshotInfo,
+				"changes": dluparser.Diff(a.Schedule, b.Schedule),
+			})
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// eventUID is stable for the same session across requests, so calendar
+// clients update subscribed events instead of duplicating them.
+func eventUID(s dluparser.Schedule, day string, sub dluparser.Subject, run [2]int) string {
+	key := strings.Join([]string{s.Class, s.Week, day, strconv.Itoa(run[0]), strconv.Itoa(run[1]), sub.Name, sub.Group}, "|")
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:]) + "@dlu-api"
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// writeICSLine writes a content line, folding it at 75 octets as RFC 5545
+// requires without splitting UTF-8 sequences. Continuation lines hold 74, as
+// their leading space is the 75th.
+func writeICSLine(sb *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		sb.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	sb.WriteString(line + "\r\n")
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// renderICS emits one VEVENT per contiguous run of periods of every session.
+func renderICS(s dluparser.Schedule) ([]byte, error) {
+	if s.Meta == nil {
+		return nil, fmt.Errorf("schedule has no academic year to date it")
+	}
+	week, err := strconv.Atoi(s.Week)
+	if err != nil {
+		return nil, fmt.Errorf("invalid week %q", s.Week)
+	}
+	monday, err := weekStart(s.Meta.YearStudy, week)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	line := func(l string) { writeICSLine(&sb, l) }
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//dlu-api//DLU timetable//VI")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + icsEscaper.Replace("Lịch học "+s.Class))
+	stamp := icsTime(time.Now())
+
+	times := periodTableFor(s.Class)
+	for _, day := range dluparser.SortedDayNames(s.Days) {
+		idx, ok := dluparser.WeekdayIndex(day)
+		if !ok {
+			continue
+		}
+		date := monday.AddDate(0, 0, idx-1)
+		d := s.Days[day]
+		for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range slot {
+				for _, run := range periodRuns(sub.Periods) {
+					start, end, ok := times.span(run[0], run[1])
+					if !ok {
+						continue
+					}
+					line("BEGIN:VEVENT")
+					line("UID:" + eventUID(s, day, sub, run))
+					line("DTSTAMP:" + stamp)
+					line("DTSTART:" + icsTime(date.Add(time.Duration(start)*time.Minute)))
+					line("DTEND:" + icsTime(date.Add(time.Duration(end)*time.Minute)))
+					line("SUMMARY:" + icsEscaper.Replace(sub.Name))
+					if sub.Room != "" {
+						line("LOCATION:" + icsEscaper.Replace(sub.Room))
+					}
+					desc := fmt.Sprintf("GV: %s\nNhóm: %s\nTiết: %s\nĐã học: %s", sub.Teacher, sub.Group, sub.Period, sub.Lessons)
+					if sub.SectionLabel != "" {
+						desc = "Lớp học phần: " + sub.SectionLabel + "\n" + desc
+					}
+					line("DESCRIPTION:" + icsEscaper.Replace(desc))
+					line("END:VEVENT")
+				}
+			}
+		}
+	}
+	line("END:VCALENDAR")
+	return []byte(sb.String()), nil
+}
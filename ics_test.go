@@ -0,0 +1,104 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"dlu-api/pkg/dluparser"
+)
+
+func TestWriteICSLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Lập trình Go"},
+		{"exactly 75", "DESCRIPTION:" + strings.Repeat("a", 63)},
+		{"ascii", "DESCRIPTION:" + strings.Repeat("a", 200)},
+		{"multibyte", "DESCRIPTION:" + strings.Repeat("Cơ sở dữ liệu ", 20)},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		writeICSLine(&sb, tt.line)
+		out := sb.String()
+		if !strings.HasSuffix(out, "\r\n") {
+			t.Fatalf("%s: not CRLF-terminated: %q", tt.name, out)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+		var unfolded strings.Builder
+		for i, l := range lines {
+			if len(l) > 75 {
+				t.Errorf("%s: line %d is %d octets", tt.name, i, len(l))
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("%s: line %d splits a UTF-8 sequence: %q", tt.name, i, l)
+			}
+			if i > 0 {
+				if !strings.HasPrefix(l, " ") {
+					t.Errorf("%s: continuation %d doesn't start with a space", tt.name, i)
+				}
+				l = l[1:]
+			}
+			unfolded.WriteString(l)
+		}
+		if unfolded.String() != tt.line {
+			t.Errorf("%s: unfolds to %q", tt.name, unfolded.String())
+		}
+	}
+}
+
+// icsUIDs returns the sorted UID lines of an iCalendar body.
+func icsUIDs(t *testing.T, s dluparser.Schedule) []string {
+	t.Helper()
+	out, err := renderICS(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uids []string
+	for _, l := range strings.Split(string(out), "\r\n") {
+		if strings.HasPrefix(l, "UID:") {
+			uids = append(uids, l)
+		}
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+func TestEventUIDStable(t *testing.T) {
+	week := func(reorder bool) dluparser.Schedule {
+		sang := []dluparser.Subject{
+			{Name: "Lập trình Go", Group: "1", Periods: []int{1, 2, 3}},
+			{Name: "Toán rời rạc", Group: "2", Periods: []int{4, 5}},
+		}
+		if reorder {
+			sang[0], sang[1] = sang[1], sang[0]
+		}
+		return dluparser.Schedule{
+			Class: "CTK45",
+			Week:  "3",
+			Meta:  &dluparser.Meta{YearStudy: "2024-2025"},
+			Days: map[string]dluparser.DaySchedule{
+				"Thứ 2": {Sang: sang},
+				"Thứ 4": {Chieu: []dluparser.Subject{{Name: "Cơ sở dữ liệu", Group: "2", Periods: []int{7, 8, 9}}}},
+			},
+		}
+	}
+	first := icsUIDs(t, week(false))
+	if len(first) != 3 {
+		t.Fatalf("got %d events, want 3: %v", len(first), first)
+	}
+	for _, tt := range []struct {
+		name string
+		s    dluparser.Schedule
+	}{
+		{"rendered again", week(false)},
+		{"reordered day", week(true)},
+	} {
+		if got := icsUIDs(t, tt.s); !slices.Equal(got, first) {
+			t.Errorf("%s: UIDs %v, want %v", tt.name, got, first)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	maxBodyBytes   = envInt("DLU_MAX_BODY_BYTES", 1<<20)
+	maxHeaderBytes = envInt("DLU_MAX_HEADER_BYTES", 64<<10)
+)
+
+// limitBody rejects declared oversized bodies up front and caps the rest while
+// they are read, so handlers see an *http.MaxBytesError instead of unbounded
+// input.
+func limitBody(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
+
+// bindJSON decodes the request body into v, answering 413 when the body limit
+// was hit and 400 for malformed input. It reports whether decoding succeeded.
+func bindJSON(c *gin.Context, v any) bool {
+	err := c.ShouldBindJSON(v)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	return false
+}
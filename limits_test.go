@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	prev := maxBodyBytes
+	maxBodyBytes = 1 << 10
+	t.Cleanup(func() { maxBodyBytes = prev })
+	h := newTestServer(t, servePage(t, "mau2.html"))
+
+	small := `["Tuần 3 lớp: CTK45"]`
+	large := `["` + strings.Repeat("x", 2<<10) + `"]`
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"within limit", small, false, http.StatusOK},
+		{"declared too large", large, false, http.StatusRequestEntityTooLarge},
+		{"streamed too large", large, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		var body io.Reader = strings.NewReader(tt.body)
+		if tt.chunked {
+			// Hide the length so the limit is only hit while reading.
+			body = io.MultiReader(body)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/dlu/parse/bulk", body)
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
+
+func TestHeaderLimit(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config.MaxHeaderBytes = maxHeaderBytes
+	srv.Start()
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		size int
+		want int
+	}{
+		{100, http.StatusOK},
+		// net/http allows a little slack over the limit.
+		{2 * maxHeaderBytes, http.StatusRequestHeaderFieldsTooLarge},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/dlu/capabilities", nil)
+		req.Header.Set("X-Padding", string(bytes.Repeat([]byte("x"), tt.size)))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%d byte header: status %d, want %d", tt.size, resp.StatusCode, tt.want)
+		}
+	}
+}
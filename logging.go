@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var logFormat = envString("DLU_LOG_FORMAT", "json")
+
+// setupLogging routes slog, and through it the standard log package, to a
+// JSON (or text) handler on stderr at the configured level.
+func setupLogging(level slog.Level) {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewJSONHandler(os.Stderr, opts)
+	if logFormat == "text" {
+		h = slog.NewTextHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(h))
+}
+
+// requestStats accumulates what a request cost upstream, for its log line.
+type requestStats struct {
+	upstreamNanos atomic.Int64
+	upstreamCalls atomic.Int32
+}
+
+type statsKey struct{}
+
+func statsFrom(ctx context.Context) *requestStats {
+	s, _ := ctx.Value(statsKey{}).(*requestStats)
+	return s
+}
+
+// recordUpstream adds an upstream call's duration to the request in ctx, if
+// any.
+func recordUpstream(ctx context.Context, d time.Duration) {
+	if s := statsFrom(ctx); s != nil {
+		s.upstreamNanos.Add(int64(d))
+		s.upstreamCalls.Add(1)
+	}
+}
+
+// requestIDRe limits accepted X-Request-ID values to something safe to log
+// and echo.
+var requestIDRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestLogger replaces Gin's text logger with one structured line per
+// request, and tags every request with an X-Request-ID (the client's, if it
+// sent a usable one).
+func requestLogger(c *gin.Context) {
+	start := time.Now()
+	id := c.GetHeader("X-Request-ID")
+	if !requestIDRe.MatchString(id) {
+		id = randomHex(8)
+	}
+	c.Set("request_id", id)
+	c.Header("X-Request-ID", id)
+
+	stats := &requestStats{}
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), statsKey{}, stats))
+
+	c.Next()
+
+	attrs := []any{
+		"request_id", id,
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"query", c.Request.URL.RawQuery,
+		"status", c.Writer.Status(),
+		"latency_ms", time.Since(start).Milliseconds(),
+		"client_ip", c.ClientIP(),
+	}
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+		attrs = append(attrs, "trace_id", sc.TraceID().String())
+	}
+	if n := stats.upstreamCalls.Load(); n > 0 {
+		attrs = append(attrs, "upstream_calls", n, "upstream_ms", time.Duration(stats.upstreamNanos.Load()).Milliseconds())
+	}
+	if key := c.GetString("apiKey"); key != "" {
+		attrs = append(attrs, "api_key", key)
+	}
+	if cache := c.Writer.Header().Get("X-Cache"); cache != "" {
+		attrs = append(attrs, "cache", cache)
+	}
+	if len(c.Errors) > 0 {
+		attrs = append(attrs, "errors", c.Errors.String())
+	}
+
+	level := slog.LevelInfo
+	if c.Writer.Status() >= 500 {
+		level = slog.LevelError
+	}
+	slog.Log(c.Request.Context(), level, "request", attrs...)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestServer points the API at a fake portal for the duration of a test
+// and returns the API's handler, with an empty cache.
+func newTestServer(t *testing.T, portal http.HandlerFunc) http.Handler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(portal)
+	t.Cleanup(upstream.Close)
+
+	prevURL, prevProviders, prevCache := upstreamURL, providers, weekCache
+	t.Cleanup(func() { upstreamURL, providers, weekCache = prevURL, prevProviders, prevCache })
+	upstreamURL = upstream.URL + "/schedule"
+	p, err := loadProviders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers = p
+	weekCache = &scheduleCache{entries: map[scheduleQuery]cacheEntry{}, refreshing: map[scheduleQuery]bool{}}
+
+	r := gin.New()
+	r.Use(traceRequests, apiKeyAuth, limitBody(int64(maxBodyBytes)))
+	registerRoutes(r)
+	return stripTrailingSlash(r)
+}
+
+// servePage answers every portal request with a fixture from
+// pkg/dluparser/testdata.
+func servePage(t *testing.T, name string) http.HandlerFunc {
+	t.Helper()
+	page, err := os.ReadFile("pkg/dluparser/testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}
+}
+
+func get(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+const weekParams = "YearStudy=2024-2025&TermID=HK01&Week=3"
+
+func TestClassMismatch(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	tests := []struct {
+		class    string
+		mismatch bool
+	}{
+		{"CTK45", false},
+		{"ctk45", false},
+		{"CTK46", true},
+	}
+	for _, tt := range tests {
+		rec := get(t, h, "/dlu?"+weekParams+"&ClassStudentID="+tt.class)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.class, rec.Code, rec.Body)
+		}
+		var s dluparser.Schedule
+		if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+			t.Fatal(err)
+		}
+		got := false
+		for _, w := range s.Warnings {
+			if w.Code == "classMismatch" {
+				got = true
+				if !strings.Contains(w.Message, "CTK45") {
+					t.Errorf("%s: warning %q doesn't name the class served", tt.class, w.Message)
+				}
+			}
+		}
+		if got != tt.mismatch {
+			t.Errorf("%s: classMismatch warning = %v, want %v (warnings %v)", tt.class, got, tt.mismatch, s.Warnings)
+		}
+	}
+}
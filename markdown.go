@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+)
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "<", "&lt;")
+
+// renderMarkdown writes the week as a table with a row per day and a column
+// per session. Table cells can't hold line breaks, so a subject's lines and
+// the subjects sharing a session are separated with <br>, which GitHub and
+// Notion render.
+func renderMarkdown(s dluparser.Schedule) ([]byte, error) {
+	var b strings.Builder
+	title, subtitle := scheduleHeading(s)
+	b.WriteString("### " + markdownEscaper.Replace(title) + "\n\n")
+	if subtitle != "" {
+		b.WriteString(markdownEscaper.Replace(subtitle) + "\n\n")
+	}
+
+	b.WriteString("| Thứ | " + strings.Join(dluparser.Slots, " | ") + " |\n")
+	b.WriteString("|---" + strings.Repeat("|---", len(dluparser.Slots)) + "|\n")
+
+	days := daysByIndex(s)
+	for n := 1; n <= 7; n++ {
+		day := days[n]
+		name := dluparser.VietnameseDayNames[n]
+		if day.Date != "" {
+			name += " (" + shortDate(day.Date) + ")"
+		}
+		b.WriteString("| " + name)
+		for _, subjects := range [][]dluparser.Subject{day.Sang, day.Chieu, day.Toi} {
+			var cell []string
+			for _, sub := range subjects {
+				lines := strings.Split(sessionText(sub), "\n")
+				for i, l := range lines {
+					lines[i] = markdownEscaper.Replace(l)
+				}
+				lines[0] = "**" + lines[0] + "**"
+				if sub.Period != "" {
+					lines = append(lines, "Tiết: "+markdownEscaper.Replace(sub.Period))
+				}
+				cell = append(cell, strings.Join(lines, "<br>"))
+			}
+			b.WriteString(" | " + strings.Join(cell, "<br><br>"))
+		}
+		b.WriteString(" |\n")
+	}
+	return []byte(b.String()), nil
+}
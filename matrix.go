@@ -0,0 +1,51 @@
+package main
+
+import "dlu-api/pkg/dluparser"
+
+// Matrix is a days × slots grid of subject names for rendering a timetable
+// directly. Rows always run Monday→Sunday and columns Sáng→Tối; a cell with
+// no subjects is null.
+type Matrix struct {
+	Class string          `json:"class"`
+	Week  string          `json:"week"`
+	Days  []string        `json:"days"`
+	Slots []string        `json:"slots"`
+	Cells [][][]string    `json:"cells"`
+	Meta  *dluparser.Meta `json:"meta,omitempty"`
+}
+
+func matrixView(s dluparser.Schedule) Matrix {
+	m := Matrix{
+		Class: s.Class,
+		Week:  s.Week,
+		Slots: dluparser.Slots,
+		Meta:  s.Meta,
+	}
+
+	byIndex := make(map[int]dluparser.DaySchedule, len(s.Days))
+	names := make(map[int]string, len(s.Days))
+	for name, d := range s.Days {
+		if n, ok := dluparser.WeekdayIndex(name); ok {
+			byIndex[n] = dluparser.MergeDays(byIndex[n], d)
+			names[n] = name
+		}
+	}
+
+	for n := 1; n <= 7; n++ {
+		name, ok := names[n]
+		if !ok {
+			name = dluparser.VietnameseDayNames[n]
+		}
+		m.Days = append(m.Days, name)
+
+		d := byIndex[n]
+		row := make([][]string, len(dluparser.Slots))
+		for i, subjects := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range subjects {
+				row[i] = append(row[i], sub.Name)
+			}
+		}
+		m.Cells = append(m.Cells, row)
+	}
+	return m
+}
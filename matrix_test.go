@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestMatrixView(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	rec := get(t, h, "/dlu?"+weekParams+"&ClassStudentID=CTK45&view=matrix")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var m struct {
+		Days  []string      `json:"days"`
+		Slots []string      `json:"slots"`
+		Cells [][][]*string `json:"cells"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	wantDays := []string{"Thứ 2", "Thứ 3", "Thứ 4", "Thứ 5", "Thứ 6", "Thứ 7", "Chủ nhật"}
+	if !slices.Equal(m.Days, wantDays) {
+		t.Errorf("days = %v, want %v", m.Days, wantDays)
+	}
+	if want := []string{"Sáng", "Chiều", "Tối"}; !slices.Equal(m.Slots, want) {
+		t.Errorf("slots = %v, want %v", m.Slots, want)
+	}
+	if len(m.Cells) != 7 {
+		t.Fatalf("%d rows, want 7", len(m.Cells))
+	}
+	for i, row := range m.Cells {
+		if len(row) != 3 {
+			t.Errorf("row %d has %d cells, want 3", i, len(row))
+		}
+	}
+
+	// The fixture has Monday morning and Wednesday afternoon; every other
+	// cell is an explicit null.
+	filled := map[[2]int]string{{0, 0}: "Lập trình Go", {2, 1}: "Cơ sở dữ liệu"}
+	for i, row := range m.Cells {
+		for j, cell := range row {
+			want, ok := filled[[2]int{i, j}]
+			switch {
+			case !ok && cell != nil:
+				t.Errorf("cell %d,%d = %v, want null", i, j, cell)
+			case ok && (len(cell) != 1 || cell[0] == nil || *cell[0] != want):
+				t.Errorf("cell %d,%d = %v, want [%s]", i, j, cell, want)
+			}
+		}
+	}
+	if !containsNull(rec.Body.Bytes()) {
+		t.Errorf("empty cells aren't written as null: %s", rec.Body)
+	}
+}
+
+func containsNull(b []byte) bool {
+	var raw struct {
+		Cells [][]json.RawMessage `json:"cells"`
+	}
+	json.Unmarshal(b, &raw)
+	for _, row := range raw.Cells {
+		for _, cell := range row {
+			if string(cell) == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
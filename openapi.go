@@ -0,0 +1,208 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// marshalledAs lists fields whose JSON form differs from their Go type
+// because of a custom MarshalJSON, keyed by struct and JSON name.
+var marshalledAs = map[reflect.Type]map[string]reflect.Type{
+	reflect.TypeOf(dluparser.Schedule{}): {"days": reflect.TypeOf([]dluparser.Day{})},
+}
+
+// schemaBuilder turns Go types into OpenAPI schemas, collecting named
+// structs as components.
+type schemaBuilder struct {
+	components map[string]any
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct:
+		name := t.Name()
+		if _, ok := b.components[name]; !ok {
+			b.components[name] = nil // placeholder for recursive types
+			b.components[name] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{}
+}
+
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	b.fields(t, props)
+	return map[string]any{"type": "object", "properties": props}
+}
+
+// fields adds t's JSON fields to props, flattening embedded structs the way
+// encoding/json does.
+func (b *schemaBuilder) fields(t reflect.Type, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			b.fields(f.Type, props)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		ft := f.Type
+		if as, ok := marshalledAs[t][name]; ok {
+			ft = as
+		}
+		props[name] = b.schema(ft)
+	}
+}
+
+var openAPISpec = sync.OnceValue(buildOpenAPI)
+
+// buildOpenAPI describes the routes table. Handlers that answer with gin.H
+// have no response type and are documented without a body schema.
+func buildOpenAPI() map[string]any {
+	b := &schemaBuilder{components: map[string]any{}}
+	b.components["Error"] = map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"error": map[string]any{"type": "string"}},
+	}
+
+	paths := map[string]any{}
+	for _, rt := range routes {
+		path, params := openAPIPath(rt.Path)
+		for _, name := range rt.Query {
+			params = append(params, map[string]any{"name": name, "in": "query", "schema": map[string]any{"type": "string"}})
+		}
+
+		ok := map[string]any{"description": "OK"}
+		if rt.response != nil {
+			ok["content"] = map[string]any{"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(rt.response))}}
+		}
+		op := map[string]any{
+			"operationId": operationID(rt.Method, rt.Path),
+			"responses": map[string]any{
+				"200": ok,
+				"default": map[string]any{
+					"description": "Error",
+					"content":     map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}}},
+				},
+			},
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if rt.Admin {
+			op["security"] = []any{map[string]any{"adminKey": []string{}}}
+		}
+
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	spec := map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": "dlu-api", "version": "1"},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": b.components,
+			"securitySchemes": map[string]any{
+				"adminKey": map[string]any{"type": "apiKey", "in": "header", "name": "X-Admin-Key"},
+				"apiKey":   map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+	if apiKeys != nil {
+		security := []any{map[string]any{"apiKey": []string{}}}
+		if !apiKeyRequired {
+			security = append(security, map[string]any{})
+		}
+		spec["security"] = security
+	}
+	return spec
+}
+
+// openAPIPath rewrites gin's ":id" segments as "{id}" and declares them.
+func openAPIPath(path string) (string, []any) {
+	var params []any
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		if name, ok := strings.CutPrefix(s, ":"); ok {
+			segs[i] = "{" + name + "}"
+			params = append(params, map[string]any{"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+	}
+	return strings.Join(segs, "/"), params
+}
+
+// operationID derives a stable name such as getV1ClassesClassIDSchedule for
+// SDK generators.
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, s := range strings.FieldsFunc(path, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		r := []rune(s)
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+	return sb.String()
+}
+
+func openAPIHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, openAPISpec())
+}
+
+// docsPage loads Swagger UI from unpkg and points it at /openapi.json.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>dlu-api</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+func docsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+type scheduleQuery struct {
+	YearStudy      string
+	TermID         string
+	Week           string
+	ClassStudentID string
+	Semester       string
+	// Provider names the portal for /u/:provider routes; empty is DLU.
+	Provider string
+}
+
+var errMissingParams = errors.New("Missing query parameters")
+
+var (
+	yearStudyRe = regexp.MustCompile(`^(\d{4})-(\d{4})$`)
+	termIDRe    = regexp.MustCompile(`(?i)^HK0?(\d)$`)
+	classIDRe   = regexp.MustCompile(`^[\p{L}\p{N}]+$`)
+)
+
+func bindScheduleQuery(c *gin.Context) (scheduleQuery, error) {
+	q := queryFromRequest(c)
+	if q.ClassStudentID == "" {
+		return q, errMissingParams
+	}
+	return q.resolve()
+}
+
+// bindWeekQuery reads only the year/term/week part of a query, for endpoints
+// that pick the classes themselves.
+func bindWeekQuery(c *gin.Context) (scheduleQuery, error) {
+	q := queryFromRequest(c)
+	q.ClassStudentID = ""
+	return q.resolve()
+}
+
+// bindTermQuery reads year/term/class for pages that aren't split into weeks.
+func bindTermQuery(c *gin.Context) (scheduleQuery, error) {
+	q := queryFromRequest(c)
+	if q.ClassStudentID == "" {
+		return q, errMissingParams
+	}
+	q.Week = "1"
+	q, err := q.resolve()
+	q.Week = ""
+	return q, err
+}
+
+func queryFromRequest(c *gin.Context) scheduleQuery {
+	q := scheduleQuery{
+		YearStudy:      c.Query("YearStudy"),
+		TermID:         c.Query("TermID"),
+		Week:           c.Query("Week"),
+		ClassStudentID: c.Query("ClassStudentID"),
+		Semester:       c.Query("semester"),
+	}
+	if p := c.Param("provider"); p != defaultProvider {
+		q.Provider = p
+	}
+	return q
+}
+
+// queryAny returns the first of the named query parameters that is set.
+func queryAny(c *gin.Context, names ...string) string {
+	for _, name := range names {
+		if v := c.Query(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// resolve expands a semester label, checks required fields and normalizes.
+func (q scheduleQuery) resolve() (scheduleQuery, error) {
+	if q.Semester != "" {
+		s, err := resolveSemester(q.Semester)
+		if err != nil {
+			return q, err
+		}
+		q.YearStudy, q.TermID = s.YearStudy, s.TermID
+	}
+
+	if q.YearStudy == "" || q.TermID == "" || q.Week == "" {
+		return q, errMissingParams
+	}
+	return q.normalize()
+}
+
+// normalize validates the parameters and rewrites them into the exact form
+// the upstream expects (e.g. "hk1" becomes "HK01").
+func (q scheduleQuery) normalize() (scheduleQuery, error) {
+	q.YearStudy = strings.TrimSpace(q.YearStudy)
+	m := yearStudyRe.FindStringSubmatch(q.YearStudy)
+	if m == nil {
+		return q, fmt.Errorf("invalid YearStudy %q, expected e.g. 2025-2026", q.YearStudy)
+	}
+	start, _ := strconv.Atoi(m[1])
+	if end, _ := strconv.Atoi(m[2]); end != start+1 {
+		return q, fmt.Errorf("invalid YearStudy %q, years must be consecutive", q.YearStudy)
+	}
+
+	t := termIDRe.FindStringSubmatch(strings.TrimSpace(q.TermID))
+	if t == nil {
+		return q, fmt.Errorf("invalid TermID %q, expected e.g. HK01", q.TermID)
+	}
+	q.TermID = "HK0" + t[1]
+
+	week, err := strconv.Atoi(strings.TrimSpace(q.Week))
+	if alias := strings.ToLower(strings.TrimSpace(q.Week)); alias == "current" || alias == "next" {
+		if week, err = weekAt(q.YearStudy, time.Now()); err != nil {
+			return q, err
+		}
+		if alias == "next" {
+			week++
+		}
+	}
+	if err != nil || week < 1 {
+		return q, fmt.Errorf("invalid Week %q, expected a positive number, current or next", q.Week)
+	}
+	q.Week = strconv.Itoa(week)
+
+	if q.ClassStudentID != "" {
+		q.ClassStudentID = dluparser.NormalizeClassCode(q.ClassStudentID)
+		if !classIDRe.MatchString(q.ClassStudentID) {
+			return q, fmt.Errorf("invalid ClassStudentID %q", q.ClassStudentID)
+		}
+	}
+	return q, nil
+}
+
+// normalizeClassIDs validates and normalizes a list of class IDs, dropping
+// blanks and duplicates.
+func normalizeClassIDs(ids []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		id = dluparser.NormalizeClassCode(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		if !classIDRe.MatchString(id) {
+			return nil, fmt.Errorf("invalid ClassStudentID %q", id)
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	if len(out) == 0 {
+		return nil, errMissingParams
+	}
+	return out, nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"dlu-api/pkg/dluparser"
+)
+
+const (
+	pdfPageW   = 842.0 // A4 landscape, in points
+	pdfPageH   = 595.0
+	pdfMargin  = 36.0
+	pdfDayCol  = 80.0
+	pdfSize    = 9.0
+	pdfLeading = 11.0
+	pdfPad     = 4.0
+	pdfHeadRow = 18.0
+)
+
+type pdfDoc struct {
+	font  *ttfFont
+	used  map[uint16]rune
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+func (d *pdfDoc) text(x, y, size float64, s string) {
+	fmt.Fprintf(d.page, "0 g BT /F1 %.1f Tf %.2f %.2f Td <", size, x, y)
+	for _, r := range s {
+		g := d.font.glyph(r)
+		d.used[g] = r
+		fmt.Fprintf(d.page, "%04X", g)
+	}
+	d.page.WriteString("> Tj ET\n")
+}
+
+// cell draws a bordered box, filled with the RGB color when one is given.
+func (d *pdfDoc) cell(x, y, w, h float64, fill ...float64) {
+	if len(fill) == 3 {
+		fmt.Fprintf(d.page, "%.2f %.2f %.2f rg %.2f %.2f %.2f %.2f re f\n", fill[0], fill[1], fill[2], x, y, w, h)
+	}
+	fmt.Fprintf(d.page, "0.5 w 0 G %.2f %.2f %.2f %.2f re S\n", x, y, w, h)
+}
+
+func (d *pdfDoc) wrap(s string, width float64) []string {
+	return wrapText(d.font, s, pdfSize, width)
+}
+
+func (d *pdfDoc) cellLines(subjects []dluparser.Subject, width float64) []string {
+	var lines []string
+	for i, sub := range subjects {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		text := sessionText(sub)
+		if sub.Period != "" {
+			text += "\nTiết: " + sub.Period
+		}
+		for _, l := range strings.Split(text, "\n") {
+			lines = append(lines, d.wrap(l, width)...)
+		}
+	}
+	return lines
+}
+
+// renderPDF draws the week as a table with a row per day and a column per
+// session, continuing onto further pages if it doesn't fit on one.
+func renderPDF(s dluparser.Schedule) ([]byte, error) {
+	d := &pdfDoc{font: renderFont, used: map[uint16]rune{}}
+	slotW := (pdfPageW - 2*pdfMargin - pdfDayCol) / float64(len(dluparser.Slots))
+
+	title, subtitle := scheduleHeading(s)
+
+	var y float64
+	header := func() {
+		y -= pdfHeadRow
+		d.cell(pdfMargin, y, pdfDayCol, pdfHeadRow, 0.85, 0.88, 0.95)
+		d.text(pdfMargin+pdfPad, y+5, pdfSize, "Thứ")
+		for i, slot := range dluparser.Slots {
+			x := pdfMargin + pdfDayCol + float64(i)*slotW
+			d.cell(x, y, slotW, pdfHeadRow, 0.85, 0.88, 0.95)
+			d.text(x+pdfPad, y+5, pdfSize, slot)
+		}
+	}
+
+	d.newPage()
+	y = pdfPageH - pdfMargin - 14
+	d.text(pdfMargin, y, 14, title)
+	if subtitle != "" {
+		y -= 14
+		d.text(pdfMargin, y, pdfSize, subtitle)
+	}
+	y -= 8
+	header()
+
+	days := daysByIndex(s)
+	for n := 1; n <= 7; n++ {
+		day := days[n]
+		dayLines := []string{dluparser.VietnameseDayNames[n]}
+		if day.Date != "" {
+			dayLines = append(dayLines, shortDate(day.Date))
+		}
+		cells := make([][]string, len(dluparser.Slots))
+		rows := len(dayLines)
+		for i, subjects := range [][]dluparser.Subject{day.Sang, day.Chieu, day.Toi} {
+			cells[i] = d.cellLines(subjects, slotW-2*pdfPad)
+			rows = max(rows, len(cells[i]))
+		}
+		h := max(30, float64(rows)*pdfLeading+2*pdfPad)
+		if y-h < pdfMargin {
+			d.newPage()
+			y = pdfPageH - pdfMargin
+			header()
+		}
+		y -= h
+
+		d.cell(pdfMargin, y, pdfDayCol, h, 0.95, 0.95, 0.95)
+		for j, l := range dayLines {
+			d.text(pdfMargin+pdfPad, y+h-pdfPad-pdfSize-float64(j)*pdfLeading, pdfSize, l)
+		}
+		for i, lines := range cells {
+			x := pdfMargin + pdfDayCol + float64(i)*slotW
+			if len(lines) > 0 {
+				d.cell(x, y, slotW, h, 1, 0.95, 0.8)
+			} else {
+				d.cell(x, y, slotW, h)
+			}
+			for j, l := range lines {
+				if l != "" {
+					d.text(x+pdfPad, y+h-pdfPad-pdfSize-float64(j)*pdfLeading, pdfSize, l)
+				}
+			}
+		}
+	}
+	return d.bytes()
+}
+
+func deflate(b []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	return buf.Bytes()
+}
+
+// bytes assembles the file. Objects 1–7 are the catalog, page tree and
+// font; each page then takes two objects, itself and its content stream.
+func (d *pdfDoc) bytes() ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		z := deflate(data)
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n<< %s /Filter /FlateDecode /Length %d >>\nstream\n", len(offsets), dict, len(z))
+		out.Write(z)
+		out.WriteString("\nendstream\nendobj\n")
+	}
+
+	out.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+	f := d.font
+	scale := func(v int) int { return v * 1000 / f.unitsPerEm }
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 8+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [4 0 R] /ToUnicode 6 0 R >>", f.name))
+
+	gids := make([]uint16, 0, len(d.used))
+	for g := range d.used {
+		gids = append(gids, g)
+	}
+	slices.Sort(gids)
+	var widths strings.Builder
+	for _, g := range gids {
+		if int(g) < len(f.advances) {
+			fmt.Fprintf(&widths, "%d [%d] ", g, scale(f.advances[g]))
+		}
+	}
+	obj(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor 5 0 R /CIDToGIDMap /Identity /W [%s] >>", f.name, widths.String()))
+	obj(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 7 0 R >>",
+		f.name, scale(f.bbox[0]), scale(f.bbox[1]), scale(f.bbox[2]), scale(f.bbox[3]), scale(f.ascent), scale(f.descent), scale(f.ascent)))
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		block := gids[start:min(start+100, len(gids))]
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(block))
+		for _, g := range block {
+			fmt.Fprintf(&cmap, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune{d.used[g]}) {
+				fmt.Fprintf(&cmap, "%04X", u)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	stream("", []byte(cmap.String()))
+	stream(fmt.Sprintf("/Length1 %d", len(f.data)), f.data)
+
+	for i, page := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageW, pdfPageH, 9+2*i))
+		stream("", page.Bytes())
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
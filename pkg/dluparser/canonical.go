@@ -0,0 +1,56 @@
+package dluparser
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+)
+
+func compareSubjects(a, b Subject) int {
+	return cmp.Or(
+		cmp.Compare(a.PeriodStart, b.PeriodStart),
+		cmp.Compare(a.Period, b.Period),
+		cmp.Compare(a.Name, b.Name),
+		cmp.Compare(a.Code, b.Code),
+		cmp.Compare(a.Group, b.Group),
+		cmp.Compare(a.Class, b.Class),
+		cmp.Compare(a.Room, b.Room),
+		cmp.Compare(a.Teacher, b.Teacher),
+		cmp.Compare(a.Lessons, b.Lessons),
+	)
+}
+
+func sortedSubjects(subjects []Subject) []Subject {
+	if len(subjects) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(subjects)
+	slices.SortFunc(sorted, compareSubjects)
+	return sorted
+}
+
+// CanonicalJSON serializes the content of a schedule (class, week and days,
+// not meta or warnings) with subjects in a fixed order, so equivalent
+// schedules always produce identical bytes. Days come out in the fixed
+// Monday→Sunday order of MarshalJSON, with names breaking ties, and undated
+// since WeekStart isn't copied.
+func CanonicalJSON(s Schedule) []byte {
+	days := make(map[string]DaySchedule, len(s.Days))
+	for name, d := range s.Days {
+		days[name] = DaySchedule{
+			Sang:  sortedSubjects(d.Sang),
+			Chieu: sortedSubjects(d.Chieu),
+			Toi:   sortedSubjects(d.Toi),
+		}
+	}
+	b, _ := json.Marshal(Schedule{Class: s.Class, Week: s.Week, Days: days})
+	return b
+}
+
+// Checksum is the hex SHA-256 of CanonicalJSON.
+func Checksum(s Schedule) string {
+	sum := sha256.Sum256(CanonicalJSON(s))
+	return hex.EncodeToString(sum[:])
+}
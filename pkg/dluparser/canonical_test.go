@@ -0,0 +1,44 @@
+package dluparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	goLang := Subject{Name: "Lập trình Go", Code: "INF123", Group: "1", Class: "CTK45", Period: "1-3", PeriodStart: 1, PeriodEnd: 3, Room: "A1.101"}
+	db := Subject{Name: "Cơ sở dữ liệu", Code: "INF201", Group: "2", Class: "CTK45", Period: "4-5", PeriodStart: 4, PeriodEnd: 5, Room: "B2.202"}
+	philosophy := Subject{Name: "Triết học", Group: "1", Class: "CTK45", Period: "7-9", PeriodStart: 7, PeriodEnd: 9}
+
+	a := Schedule{Class: "CTK45", Week: "3", Days: map[string]DaySchedule{
+		"Thứ 2": {Sang: []Subject{goLang, db}},
+		"Thứ 4": {Chieu: []Subject{philosophy}},
+	}}
+	// The same week with subjects listed in another order, days built in
+	// another order, and meta and warnings that aren't content.
+	b := Schedule{Class: "CTK45", Week: "3", Days: map[string]DaySchedule{}}
+	b.Days["Thứ 4"] = DaySchedule{Chieu: []Subject{philosophy}, Toi: []Subject{}}
+	b.Days["Thứ 2"] = DaySchedule{Sang: []Subject{db, goLang}}
+	b.Meta = &Meta{YearStudy: "2024-2025", FetchedAt: "2026-01-01T00:00:00Z"}
+	b.Warnings = []Warning{{Code: "classMismatch"}}
+
+	moved := db
+	moved.Room = "C1.101"
+	c := Schedule{Class: "CTK45", Week: "3", Days: map[string]DaySchedule{
+		"Thứ 2": {Sang: []Subject{goLang, moved}},
+		"Thứ 4": {Chieu: []Subject{philosophy}},
+	}}
+
+	if !bytes.Equal(CanonicalJSON(a), CanonicalJSON(b)) {
+		t.Errorf("equivalent schedules canonicalize differently:\n%s\n%s", CanonicalJSON(a), CanonicalJSON(b))
+	}
+	if Checksum(a) != Checksum(b) {
+		t.Errorf("equivalent schedules have different checksums")
+	}
+	if Checksum(a) == Checksum(c) {
+		t.Errorf("a moved room didn't change the checksum")
+	}
+	if got := a.Days["Thứ 2"].Sang[0].Name; got != "Lập trình Go" {
+		t.Errorf("CanonicalJSON reordered its input: first subject is %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package dluparser
+
+// Conflict is a set of sessions in the same day and slot whose periods
+// overlap, typically from merged classes sharing a timetable.
+type Conflict struct {
+	Day      string    `json:"day"`
+	Slot     string    `json:"slot"`
+	Periods  []int     `json:"periods"`
+	Subjects []Subject `json:"subjects"`
+}
+
+// Conflicts lists the overlapping sessions in s, in day and slot order. Each
+// session is compared with every other in its slot, so a three-way clash is
+// reported as the pairs that actually overlap. Sessions without parsed
+// periods are skipped.
+func Conflicts(s Schedule) []Conflict {
+	var out []Conflict
+	for _, name := range SortedDayNames(s.Days) {
+		day := name
+		if n, ok := WeekdayIndex(name); ok {
+			day = VietnameseDayNames[n]
+		}
+		d := s.Days[name]
+		for i, slot := range [][]Subject{d.Sang, d.Chieu, d.Toi} {
+			for a := 0; a < len(slot); a++ {
+				for b := a + 1; b < len(slot); b++ {
+					if shared := sharedPeriods(slot[a].Periods, slot[b].Periods); len(shared) > 0 {
+						out = append(out, Conflict{day, Slots[i], shared, []Subject{slot[a], slot[b]}})
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// sharedPeriods intersects two sorted period lists.
+func sharedPeriods(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
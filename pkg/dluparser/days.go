@@ -0,0 +1,136 @@
+package dluparser
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Slots are the timetable's three daily sessions, in order.
+var Slots = []string{"Sáng", "Chiều", "Tối"}
+
+// SlotAt returns the index in Slots of the session a period starting at
+// start minutes past midnight belongs to: Sáng before noon, Chiều before
+// 17:00, Tối after.
+func SlotAt(start int) int {
+	switch {
+	case start < 12*60:
+		return 0
+	case start < 17*60:
+		return 1
+	}
+	return 2
+}
+
+// VietnameseDayNames are the canonical day labels indexed 1 (Monday) … 7
+// (Sunday); index 0 is unused.
+var VietnameseDayNames = []string{"", "Thứ 2", "Thứ 3", "Thứ 4", "Thứ 5", "Thứ 6", "Thứ 7", "Chủ nhật"}
+
+// weekdayNames maps normalized (lower-case, accent-free) day names in
+// Vietnamese and English to 1 (Monday) … 7 (Sunday). There is no "thu" for
+// Thursday: it is also "Thứ" with the accent folded, a day left unnamed.
+var weekdayNames = map[string]int{
+	"thu 2": 1, "thu hai": 1, "t2": 1, "monday": 1, "mon": 1,
+	"thu 3": 2, "thu ba": 2, "t3": 2, "tuesday": 2, "tue": 2,
+	"thu 4": 3, "thu tu": 3, "t4": 3, "wednesday": 3, "wed": 3,
+	"thu 5": 4, "thu nam": 4, "t5": 4, "thursday": 4, "thurs": 4,
+	"thu 6": 5, "thu sau": 5, "t6": 5, "friday": 5, "fri": 5,
+	"thu 7": 6, "thu bay": 6, "t7": 6, "saturday": 6, "sat": 6,
+	"chu nhat": 7, "cn": 7, "sunday": 7, "sun": 7,
+}
+
+// FoldDiacritics strips combining accents, e.g. "Thứ Hai" becomes "Thu Hai".
+func FoldDiacritics(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// WeekdayIndex resolves a day name such as "Thứ 2", "thu hai" or "Monday".
+func WeekdayIndex(name string) (int, bool) {
+	key := strings.Join(strings.Fields(strings.ToLower(FoldDiacritics(name))), " ")
+	n, ok := weekdayNames[key]
+	return n, ok
+}
+
+// SortedDayNames orders day keys Monday→Sunday, with unrecognized names last.
+func SortedDayNames(days map[string]DaySchedule) []string {
+	names := make([]string, 0, len(days))
+	for name := range days {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, okA := WeekdayIndex(names[i])
+		b, okB := WeekdayIndex(names[j])
+		if okA != okB {
+			return okA
+		}
+		if a != b {
+			return a < b
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Day is one entry of a schedule's ordered "days" array.
+type Day struct {
+	Weekday        string `json:"weekday,omitempty"`
+	VietnameseName string `json:"vietnamese_name"`
+	Date           string `json:"date,omitempty"`
+	DaySchedule
+}
+
+// OrderedDays lists the schedule's days Monday→Sunday, with unrecognized
+// names last. Dates are filled in when WeekStart is set.
+func (s Schedule) OrderedDays() []Day {
+	days := make([]Day, 0, len(s.Days))
+	for _, name := range SortedDayNames(s.Days) {
+		d := Day{VietnameseName: name, DaySchedule: s.Days[name]}
+		if n, ok := WeekdayIndex(name); ok {
+			d.Weekday = time.Weekday(n % 7).String()
+			d.VietnameseName = VietnameseDayNames[n]
+			if !s.WeekStart.IsZero() {
+				d.Date = s.WeekStart.AddDate(0, 0, n-1).Format("2006-01-02")
+			}
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// MarshalJSON writes Days as the ordered array from OrderedDays so clients
+// get a deterministic order instead of a map.
+func (s Schedule) MarshalJSON() ([]byte, error) {
+	type plain Schedule
+	return json.Marshal(struct {
+		plain
+		Days []Day `json:"days"`
+	}{plain(s), s.OrderedDays()})
+}
+
+// UnmarshalJSON accepts the ordered array written by MarshalJSON.
+func (s *Schedule) UnmarshalJSON(b []byte) error {
+	type plain Schedule
+	aux := struct {
+		*plain
+		Days []Day `json:"days"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	s.Days = make(map[string]DaySchedule, len(aux.Days))
+	for _, d := range aux.Days {
+		s.Days[d.VietnameseName] = MergeDays(s.Days[d.VietnameseName], d.DaySchedule)
+	}
+	return nil
+}
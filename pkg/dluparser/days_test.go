@@ -0,0 +1,86 @@
+package dluparser
+
+import "testing"
+
+func TestWeekdayIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+		ok   bool
+	}{
+		{"Thứ 2", 1, true},
+		{"Thứ Hai", 1, true},
+		{"thu hai", 1, true},
+		{"T2", 1, true},
+		{"Monday", 1, true},
+		{"mon", 1, true},
+		{"Thứ 3", 2, true},
+		{"Thứ Ba", 2, true},
+		{"t3", 2, true},
+		{"Tuesday", 2, true},
+		{"tue", 2, true},
+		{"Thứ 4", 3, true},
+		{"Thứ Tư", 3, true},
+		{"t4", 3, true},
+		{"Wednesday", 3, true},
+		{"wed", 3, true},
+		{"Thứ 5", 4, true},
+		{"Thứ Năm", 4, true},
+		{"t5", 4, true},
+		{"Thursday", 4, true},
+		{"thurs", 4, true},
+		{"Thứ 6", 5, true},
+		{"Thứ Sáu", 5, true},
+		{"t6", 5, true},
+		{"Friday", 5, true},
+		{"fri", 5, true},
+		{"Thứ 7", 6, true},
+		{"Thứ Bảy", 6, true},
+		{"t7", 6, true},
+		{"Saturday", 6, true},
+		{"sat", 6, true},
+		{"Chủ nhật", 7, true},
+		{"Chủ Nhật", 7, true},
+		{"CN", 7, true},
+		{"Sunday", 7, true},
+		{"sun", 7, true},
+		{"  Thứ   2 ", 1, true},
+		// Decomposed accents, as some pages send them.
+		{"Thu\u031b\u0301 2", 1, true},
+
+		// Ambiguous or unknown names don't resolve.
+		{"Thứ", 0, false},
+		{"thu", 0, false},
+		{"Thứ 8", 0, false},
+		{"Thứ 1", 0, false},
+		{"T", 0, false},
+		{"", 0, false},
+		{"Chủ", 0, false},
+		{"someday", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := WeekdayIndex(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("WeekdayIndex(%q) = %d, %v; want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSlotAt(t *testing.T) {
+	tests := []struct {
+		start, want int
+	}{
+		{7 * 60, 0},
+		{11*60 + 59, 0},
+		{12 * 60, 1},
+		{13 * 60, 1},
+		{16*60 + 59, 1},
+		{17 * 60, 2},
+		{20 * 60, 2},
+	}
+	for _, tt := range tests {
+		if got := SlotAt(tt.start); got != tt.want {
+			t.Errorf("SlotAt(%d) = %d, want %d", tt.start, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package dluparser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Change is one difference between two snapshots of a schedule.
+type Change struct {
+	Type   string   `json:"type"` // "added", "removed" or "modified"
+	Day    string   `json:"day"`
+	Slot   string   `json:"slot"`
+	Before *Subject `json:"before,omitempty"`
+	After  *Subject `json:"after,omitempty"`
+	// Fields lists what changed on a modified session: "tiet", "phong", "gv".
+	Fields []string `json:"fields,omitempty"`
+}
+
+// sessionKey identifies a session across snapshots: the same course group
+// on the same day and slot. Period, room and teacher may change under it.
+func sessionKey(day int, slot string, s Subject) string {
+	return strings.Join([]string{strconv.Itoa(day), slot, s.Name, s.Code, s.Group, s.Class}, "\x00")
+}
+
+type keyedSession struct {
+	day     string
+	slot    string
+	subject Subject
+}
+
+func sessions(s Schedule) (map[string]keyedSession, []string) {
+	out := make(map[string]keyedSession)
+	var order []string
+	for _, name := range SortedDayNames(s.Days) {
+		day, ok := WeekdayIndex(name)
+		if !ok {
+			continue
+		}
+		d := s.Days[name]
+		for i, slot := range [][]Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range slot {
+				key := sessionKey(day, Slots[i], sub)
+				if _, dup := out[key]; !dup {
+					order = append(order, key)
+				}
+				out[key] = keyedSession{VietnameseDayNames[day], Slots[i], sub}
+			}
+		}
+	}
+	return out, order
+}
+
+// Diff lists the sessions added, removed or moved between old and new, in
+// day and slot order. Lesson progress is ignored since it advances every
+// week without the timetable changing.
+func Diff(old, new Schedule) []Change {
+	before, beforeOrder := sessions(old)
+	after, afterOrder := sessions(new)
+
+	var changes []Change
+	for _, key := range beforeOrder {
+		b := before[key]
+		a, ok := after[key]
+		if !ok {
+			changes = append(changes, Change{Type: "removed", Day: b.day, Slot: b.slot, Before: &b.subject})
+			continue
+		}
+		var fields []string
+		if a.subject.Period != b.subject.Period {
+			fields = append(fields, "tiet")
+		}
+		if a.subject.Room != b.subject.Room {
+			fields = append(fields, "phong")
+		}
+		if a.subject.Teacher != b.subject.Teacher {
+			fields = append(fields, "gv")
+		}
+		if len(fields) > 0 {
+			changes = append(changes, Change{Type: "modified", Day: b.day, Slot: b.slot, Before: &b.subject, After: &a.subject, Fields: fields})
+		}
+	}
+	for _, key := range afterOrder {
+		if _, ok := before[key]; !ok {
+			a := after[key]
+			changes = append(changes, Change{Type: "added", Day: a.day, Slot: a.slot, After: &a.subject})
+		}
+	}
+
+	slices.SortStableFunc(changes, func(x, y Change) int {
+		dx, _ := WeekdayIndex(x.Day)
+		dy, _ := WeekdayIndex(y.Day)
+		if dx != dy {
+			return dx - dy
+		}
+		return slices.Index(Slots, x.Slot) - slices.Index(Slots, y.Slot)
+	})
+	return changes
+}
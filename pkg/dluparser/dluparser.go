@@ -0,0 +1,245 @@
+// Package dluparser parses the weekly timetables published by Đà Lạt
+// University's QLGD portal (qlgd.dlu.edu.vn) into structured schedules.
+//
+// The portal's HTML pages are read through the Parser interface, with one
+// implementation per layout: HTMLParser for DrawingClassStudentSchedules_Mau2
+// (also ParseHTML), DayColumnsParser for Mau1 and ListParser for Mau3.
+//
+// ParseSchedule reads the plain-text timetable format instead: a header line
+// naming the week and class, followed by one block per day with a line per
+// slot (Sáng, Chiều, Tối).
+package dluparser
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Subject is one class session in a slot. Name through Lessons hold the text
+// as published; the remaining fields are derived from it.
+type Subject struct {
+	Name    string `json:"ten_mon"`
+	Code    string `json:"ma_mon,omitempty"`
+	Group   string `json:"nhom"`
+	Class   string `json:"lop"`
+	Period  string `json:"tiet"`
+	Room    string `json:"phong"`
+	Teacher string `json:"gv"`
+	Lessons string `json:"da_hoc,omitempty"`
+
+	LessonsDone      int     `json:"lessons_done"`
+	LessonsTotal     int     `json:"lessons_total"`
+	LessonsRemaining int     `json:"lessons_remaining"`
+	ProgressPercent  float64 `json:"progress_percent"`
+
+	// SectionLabel names the course section the way students do, e.g.
+	// "INF123 - Nhóm 1"; see setSectionLabel.
+	SectionLabel string `json:"lop_hoc_phan,omitempty"`
+
+	PeriodStart int   `json:"tiet_bat_dau,omitempty"`
+	PeriodEnd   int   `json:"tiet_ket_thuc,omitempty"`
+	Periods     []int `json:"cac_tiet,omitempty"`
+
+	// StartTime and EndTime ("07:00") are filled in by callers that know the
+	// bell schedule; the page itself only lists periods.
+	StartTime string `json:"gio_bat_dau,omitempty"`
+	EndTime   string `json:"gio_ket_thuc,omitempty"`
+}
+
+// DaySchedule holds a day's subjects per slot.
+type DaySchedule struct {
+	Sang  []Subject `json:"sang"`
+	Chieu []Subject `json:"chieu"`
+	Toi   []Subject `json:"toi"`
+}
+
+// Meta describes the request a schedule was fetched for. The parser leaves
+// it nil.
+type Meta struct {
+	YearStudy string `json:"year_study"`
+	TermID    string `json:"term_id"`
+	Semester  string `json:"semester,omitempty"`
+	Checksum  string `json:"checksum,omitempty"`
+	// Provider is the portal the schedule came from, when it isn't DLU.
+	Provider string `json:"provider,omitempty"`
+	// Template is the portal layout the schedule was read from.
+	Template string `json:"template,omitempty"`
+	// Attempts is how many upstream requests the fetch took.
+	Attempts  int    `json:"attempts,omitempty"`
+	FetchedAt string `json:"fetched_at,omitempty"`
+	// Stale is set when a cached copy is served in place of a fresh fetch.
+	Stale bool `json:"stale,omitempty"`
+	// Cache is the cache outcome ("hit", "miss", "stale"), sent as X-Cache
+	// rather than in the body so it doesn't change the ETag.
+	Cache string `json:"-"`
+}
+
+// Warning flags a suspected problem with a schedule without failing it.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Schedule is a class's timetable for one week, keyed by day name as it
+// appears in the timetable (e.g. "Thứ 2").
+type Schedule struct {
+	Class   string                 `json:"class"`
+	Week    string                 `json:"week"`
+	Days    map[string]DaySchedule `json:"days"`
+	Summary string                 `json:"summary,omitempty"`
+	// Conflicts is filled in on request; see Conflicts.
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Meta      *Meta      `json:"meta,omitempty"`
+	Warnings  []Warning  `json:"warnings,omitempty"`
+
+	// WeekStart is the Monday of the schedule's week, used to date each day.
+	WeekStart time.Time `json:"-"`
+}
+
+// ParseHeader extracts the week number and class code from the timetable
+// header line.
+func ParseHeader(input string) (week, className string) {
+	// Match against NFC text; the upstream has been seen to send decomposed
+	// diacritics (e.g. "Tuần" with a combining grave accent).
+	re := regexp.MustCompile(`(?i)Tuần\s+(\d+).*lớp:\s*([\p{L}\p{N}]+)`)
+	matches := re.FindStringSubmatch(norm.NFC.String(input))
+	if len(matches) == 3 {
+		week = matches[1]
+		className = NormalizeClassCode(matches[2])
+	}
+	return
+}
+
+// NormalizeClassCode puts class codes into the upper-case, composed form used
+// for ClassStudentID so "ctk44a" and "CTK44A" compare equal.
+func NormalizeClassCode(code string) string {
+	return strings.ToUpper(norm.NFC.String(strings.TrimSpace(code)))
+}
+
+func splitSubjects(input string) []string {
+	input = strings.ReplaceAll(input, " tiết ", " tiết\n")
+	lines := strings.Split(input, "\n")
+	var result []string
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l != "" {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// ParseSubjects parses the subjects listed in one slot. A slot marked "Nghỉ"
+// (free) yields nil; subjects that don't match the expected layout are
+// skipped.
+func ParseSubjects(input string) []Subject {
+	if strings.Contains(input, "Nghỉ") {
+		return nil
+	}
+
+	var subjects []Subject
+	lines := splitSubjects(input)
+
+	re := regexp.MustCompile(`^(.*?)(?:\(([A-Za-z0-9][A-Za-z0-9.\-]*\d[A-Za-z0-9.\-]*)\)\s*)?- Nhóm: (\d+)- Lớp: ([\p{L}\p{N}]+)(?: - nhom \d+)?- Tiết: ([0-9][0-9,\- ]*?)\s*(?:- Phòng:\s*([A-Za-z0-9\.]*)\s*)?(?:- GV:\s*([^\-]*?)\s*)?- Đã học: (\d+/\d+)`)
+	for _, line := range lines {
+		m := re.FindStringSubmatch(line)
+		if len(m) == 9 {
+			subject := Subject{
+				Name:    strings.TrimSpace(m[1]),
+				Code:    m[2],
+				Group:   m[3],
+				Class:   NormalizeClassCode(m[4]),
+				Period:  strings.TrimSpace(m[5]),
+				Room:    m[6],
+				Teacher: strings.TrimSpace(m[7]),
+				Lessons: m[8],
+			}
+			subject.setPeriods()
+			subject.setProgress()
+			subject.setSectionLabel()
+			subjects = append(subjects, subject)
+		}
+	}
+
+	return subjects
+}
+
+func parseDay(dayLines []string) DaySchedule {
+	day := DaySchedule{}
+	for _, line := range dayLines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Sáng:") {
+			day.Sang = ParseSubjects(strings.TrimPrefix(line, "Sáng:"))
+		} else if strings.HasPrefix(line, "Chiều:") {
+			day.Chieu = ParseSubjects(strings.TrimPrefix(line, "Chiều:"))
+		} else if strings.HasPrefix(line, "Tối:") {
+			day.Toi = ParseSubjects(strings.TrimPrefix(line, "Tối:"))
+		}
+	}
+	return day
+}
+
+// MergeDays combines two parses of the same day, which happens when the
+// upstream repeats a day header, dropping subjects listed in both.
+func MergeDays(a, b DaySchedule) DaySchedule {
+	return DaySchedule{
+		Sang:  mergeSubjects(a.Sang, b.Sang),
+		Chieu: mergeSubjects(a.Chieu, b.Chieu),
+		Toi:   mergeSubjects(a.Toi, b.Toi),
+	}
+}
+
+func mergeSubjects(a, b []Subject) []Subject {
+	if len(a) == 0 {
+		return b
+	}
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []Subject
+	for _, s := range append(append([]Subject{}, a...), b...) {
+		key := strings.Join([]string{s.Name, s.Code, s.Group, s.Class, s.Period, s.Room, s.Teacher, s.Lessons}, "\x00")
+		if !seen[key] {
+			seen[key] = true
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// ParseSchedule parses a full timetable text.
+func ParseSchedule(input string) Schedule {
+	input = norm.NFC.String(input)
+	week, className := ParseHeader(input)
+	lines := strings.Split(input, "\n")
+
+	days := make(map[string]DaySchedule)
+	var currentDay string
+	var dayLines []string
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Thứ") || strings.HasPrefix(line, "Chủ nhật") {
+			if currentDay != "" {
+				days[currentDay] = MergeDays(days[currentDay], parseDay(dayLines))
+			}
+			currentDay = strings.TrimSuffix(line, ":")
+			dayLines = []string{}
+		} else {
+			dayLines = append(dayLines, line)
+		}
+	}
+	if currentDay != "" {
+		days[currentDay] = MergeDays(days[currentDay], parseDay(dayLines))
+	}
+
+	return Schedule{
+		Class: className,
+		Week:  week,
+		Days:  days,
+	}
+}
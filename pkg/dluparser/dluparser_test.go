@@ -0,0 +1,105 @@
+package dluparser
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		in          string
+		week, class string
+	}{
+		{"Lịch học Tuần 3 của lớp: CTK45", "3", "CTK45"},
+		{"Tuần 38 lớp: ctk47a", "38", "CTK47A"},
+		{"TUẦN 12 - LỚP: QTK46", "12", "QTK46"},
+		// Decomposed "Tuần", as the portal has been seen to send.
+		{"Tua\u0302\u0300n 5 lớp: CTK45", "5", "CTK45"},
+		{"Thời khóa biểu", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		week, class := ParseHeader(tt.in)
+		if week != tt.week || class != tt.class {
+			t.Errorf("ParseHeader(%q) = %q, %q; want %q, %q", tt.in, week, class, tt.week, tt.class)
+		}
+	}
+}
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRepeatedDayHeader(t *testing.T) {
+	p, err := NewHTMLParser(DefaultSelectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		fixture string
+		parse   func() Schedule
+		want    DaySchedule
+	}{
+		{
+			"repeated_day.txt",
+			func() Schedule { return ParseSchedule(readFixture(t, "repeated_day.txt")) },
+			DaySchedule{Sang: []Subject{{Name: "Lập trình Go"}}, Toi: []Subject{{Name: "Triết học"}}},
+		},
+		{
+			"repeated_day.html",
+			func() Schedule { return parseFixture(t, p, "repeated_day.html") },
+			DaySchedule{Sang: []Subject{{Name: "Lập trình Go"}}, Chieu: []Subject{{Name: "Cơ sở dữ liệu"}}},
+		},
+	}
+	for _, tt := range tests {
+		s := tt.parse()
+		mon := s.Days["Thứ 2"]
+		for _, slot := range []struct {
+			name      string
+			got, want []Subject
+		}{
+			{"Sáng", mon.Sang, tt.want.Sang},
+			{"Chiều", mon.Chieu, tt.want.Chieu},
+			{"Tối", mon.Toi, tt.want.Toi},
+		} {
+			if got, want := names(slot.got), names(slot.want); !slices.Equal(got, want) {
+				t.Errorf("%s: Thứ 2 %s = %v, want %v", tt.fixture, slot.name, got, want)
+			}
+		}
+	}
+}
+
+func TestEmptyRoomAndTeacher(t *testing.T) {
+	s := ParseSchedule(readFixture(t, "empty_fields.txt"))
+	tests := []struct {
+		day           string
+		slot          []Subject
+		name          string
+		room, teacher string
+	}{
+		{"Thứ 2 Sáng, blank room", s.Days["Thứ 2"].Sang, "Lập trình Go", "", "Nguyễn Văn A"},
+		{"Thứ 2 Chiều, blank teacher", s.Days["Thứ 2"].Chieu, "Cơ sở dữ liệu", "B2.202", ""},
+		{"Thứ 2 Tối, both blank", s.Days["Thứ 2"].Toi, "Triết học", "", ""},
+		{"Thứ 3 Sáng, no room field", s.Days["Thứ 3"].Sang, "Kinh tế học", "", "Lê Văn C"},
+		{"Thứ 3 Chiều, no teacher field", s.Days["Thứ 3"].Chieu, "Toán rời rạc", "A2.105", ""},
+	}
+	for _, tt := range tests {
+		if len(tt.slot) != 1 {
+			t.Errorf("%s: %d subjects, want 1", tt.day, len(tt.slot))
+			continue
+		}
+		sub := tt.slot[0]
+		if sub.Name != tt.name || sub.Room != tt.room || sub.Teacher != tt.teacher {
+			t.Errorf("%s: got %q room %q teacher %q; want %q room %q teacher %q", tt.day, sub.Name, sub.Room, sub.Teacher, tt.name, tt.room, tt.teacher)
+		}
+		if sub.LessonsTotal == 0 || sub.PeriodStart == 0 {
+			t.Errorf("%s: lessons and periods not parsed: %+v", tt.day, sub)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package dluparser
+
+import (
+	"io"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Exam is one row of a class's exam timetable.
+type Exam struct {
+	Subject   string `json:"ten_mon"`
+	Date      string `json:"ngay_thi"`
+	StartTime string `json:"gio_thi"`
+	Room      string `json:"phong"`
+	Format    string `json:"hinh_thuc"`
+}
+
+// examColumns maps accent-free header keywords to the Exam field they fill.
+// Headers are matched by substring so variants such as "Tên học phần" and
+// "Môn thi" both land in Subject.
+var examColumns = []struct {
+	keywords []string
+	field    func(*Exam) *string
+}{
+	{[]string{"ngay"}, func(e *Exam) *string { return &e.Date }},
+	{[]string{"gio", "ca thi", "bat dau"}, func(e *Exam) *string { return &e.StartTime }},
+	{[]string{"phong"}, func(e *Exam) *string { return &e.Room }},
+	{[]string{"hinh thuc"}, func(e *Exam) *string { return &e.Format }},
+	{[]string{"mon", "hoc phan"}, func(e *Exam) *string { return &e.Subject }},
+}
+
+// ParseExamsHTML reads the first table whose header row names a subject and
+// a date column. Columns are located by their header text, not position.
+func ParseExamsHTML(r io.Reader) ([]Exam, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	exams := []Exam{}
+	doc.Find("table").EachWithBreak(func(_ int, table *goquery.Selection) bool {
+		rows := table.Find("tr")
+		cols := examHeader(rows.First())
+		if cols == nil {
+			return true
+		}
+		rows.Slice(1, goquery.ToEnd).Each(func(_ int, row *goquery.Selection) {
+			var e Exam
+			row.Find("td").Each(func(i int, cell *goquery.Selection) {
+				if i < len(cols) && cols[i] != nil {
+					*cols[i](&e) = strings.Join(strings.Fields(norm.NFC.String(cell.Text())), " ")
+				}
+			})
+			if e.Subject != "" {
+				exams = append(exams, e)
+			}
+		})
+		return false
+	})
+	return exams, nil
+}
+
+func examHeader(row *goquery.Selection) []func(*Exam) *string {
+	var cols []func(*Exam) *string
+	var hasSubject, hasDate bool
+	row.Find("th, td").Each(func(_ int, cell *goquery.Selection) {
+		label := strings.ToLower(FoldDiacritics(strings.TrimSpace(cell.Text())))
+		var field func(*Exam) *string
+		for i, c := range examColumns {
+			if matchesAny(label, c.keywords) {
+				field = c.field
+				hasDate = hasDate || i == 0
+				hasSubject = hasSubject || i == len(examColumns)-1
+				break
+			}
+		}
+		cols = append(cols, field)
+	})
+	if !hasSubject || !hasDate {
+		return nil
+	}
+	return cols
+}
+
+func matchesAny(s string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package dluparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String reassembles the timetable text that ParseSchedule consumes, so
+// ParseSchedule(s.String()) yields an equivalent schedule.
+func (s Schedule) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Lịch học Tuần %s của lớp: %s\n\n", s.Week, s.Class)
+
+	for _, name := range SortedDayNames(s.Days) {
+		d := s.Days[name]
+		sb.WriteString(name + ":\n")
+		writeSlot(&sb, "Sáng", d.Sang)
+		writeSlot(&sb, "Chiều", d.Chieu)
+		writeSlot(&sb, "Tối", d.Toi)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func writeSlot(sb *strings.Builder, slot string, subjects []Subject) {
+	if len(subjects) == 0 {
+		sb.WriteString("  " + slot + ": Nghỉ\n")
+		return
+	}
+	parts := make([]string, len(subjects))
+	for i, s := range subjects {
+		name := s.Name
+		if s.Code != "" {
+			name += "(" + s.Code + ")"
+		}
+		parts[i] = fmt.Sprintf("%s- Nhóm: %s- Lớp: %s- Tiết: %s- Phòng: %s- GV: %s- Đã học: %s tiết",
+			name, s.Group, s.Class, s.Period, s.Room, s.Teacher, s.Lessons)
+	}
+	sb.WriteString("  " + slot + ": " + strings.Join(parts, " ") + "\n")
+}
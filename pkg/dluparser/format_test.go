@@ -0,0 +1,23 @@
+package dluparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringRoundTrip(t *testing.T) {
+	for _, fixture := range []string{"roundtrip.txt", "empty_fields.txt", "repeated_day.txt"} {
+		first := ParseSchedule(readFixture(t, fixture))
+		if len(first.Days) == 0 {
+			t.Fatalf("%s: parsed no days", fixture)
+		}
+		text := first.String()
+		second := ParseSchedule(text)
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%s: parse→format→parse changed the schedule\nformatted:\n%s\nfirst:  %+v\nsecond: %+v", fixture, text, first, second)
+		}
+		if again := second.String(); again != text {
+			t.Errorf("%s: formatting isn't stable:\n%s\nvs\n%s", fixture, text, again)
+		}
+	}
+}
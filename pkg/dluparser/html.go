@@ -0,0 +1,266 @@
+package dluparser
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Selectors locate the parts of the portal's timetable page.
+type Selectors struct {
+	Header string // element holding "Tuần … lớp: …"
+	Rows   string // timetable rows; the first is the header row
+	Day    string // day name cell within a row
+	Slot   string // slot cells within a row, in column order
+}
+
+// DefaultSelectors match the DrawingClassStudentSchedules_Mau2 layout.
+var DefaultSelectors = Selectors{
+	Header: "div > div[style]",
+	Rows:   "table tr",
+	Day:    "th",
+	Slot:   "td",
+}
+
+// HTMLParser extracts schedules straight from the timetable table.
+type HTMLParser struct {
+	Selectors                       Selectors
+	header, rows, day, slot, labels cascadia.Selector
+}
+
+// NewHTMLParser compiles sel, reporting the first invalid selector.
+func NewHTMLParser(sel Selectors) (*HTMLParser, error) {
+	p := &HTMLParser{Selectors: sel}
+	for _, s := range []struct {
+		name string
+		src  string
+		dst  *cascadia.Selector
+	}{
+		{"header", sel.Header, &p.header},
+		{"rows", sel.Rows, &p.rows},
+		{"day", sel.Day, &p.day},
+		{"slot", sel.Slot, &p.slot},
+		{"labels", "th, td", &p.labels},
+	} {
+		compiled, err := cascadia.Compile(s.src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s selector %q: %w", s.name, s.src, err)
+		}
+		*s.dst = compiled
+	}
+	return p, nil
+}
+
+var defaultHTMLParser, _ = NewHTMLParser(DefaultSelectors)
+
+// ParseHTML parses a timetable page using DefaultSelectors.
+func ParseHTML(r io.Reader) (Schedule, error) {
+	return defaultHTMLParser.Parse(r)
+}
+
+// Parse walks the timetable rows, reading the day from each row's day cell
+// and the subjects from its slot cells (see cellSubjects).
+func (p *HTMLParser) Parse(r io.Reader) (Schedule, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	week, className := ParseHeader(doc.FindMatcher(p.header).First().Text())
+	days := make(map[string]DaySchedule)
+
+	slots := Slots
+	doc.FindMatcher(p.rows).Each(func(i int, row *goquery.Selection) {
+		if i == 0 {
+			if header := p.headerSlots(row); len(header) > 0 {
+				slots = header
+			}
+			return
+		}
+		day := norm.NFC.String(strings.TrimSpace(row.FindMatcher(p.day).Text()))
+		if day == "" {
+			return
+		}
+
+		var ds DaySchedule
+		row.FindMatcher(p.slot).Each(func(j int, cell *goquery.Selection) {
+			if j >= len(slots) {
+				return
+			}
+			if cellText(cell) == "" {
+				return
+			}
+			ds.set(slots[j], cellSubjects(cell))
+		})
+		days[day] = MergeDays(days[day], ds)
+	})
+
+	return Schedule{Class: className, Week: week, Days: days}, nil
+}
+
+// cellSubjects reads the subjects in a timetable cell from its markup: each
+// subject is a name line, optionally ending in "(CODE)", followed by
+// "Label: value" lines (Nhóm, Lớp, Tiết, Phòng, GV, Đã học) separated by
+// <br> or block elements. Cells that don't have that structure, such as
+// ones holding everything on one line, are read from their text with
+// ParseSubjects.
+func cellSubjects(cell *goquery.Selection) []Subject {
+	text := cellText(cell)
+	if strings.Contains(text, "Nghỉ") {
+		return nil
+	}
+	if subjects, ok := structuredSubjects(cellLines(cell)); ok {
+		return subjects
+	}
+	return ParseSubjects(text)
+}
+
+// blockElements end a line of cell text, like <br>.
+var blockElements = map[string]bool{"div": true, "p": true, "li": true, "hr": true, "table": true, "tr": true}
+
+// cellLines splits a cell's text at <br> and block elements, collapsing
+// whitespace within each line.
+func cellLines(cell *goquery.Selection) []string {
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if l := strings.Join(strings.Fields(line.String()), " "); l != "" {
+			lines = append(lines, norm.NFC.String(l))
+		}
+		line.Reset()
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				line.WriteString(c.Data)
+			case c.Type == html.ElementNode && c.Data == "br":
+				flush()
+			case c.Type == html.ElementNode && blockElements[c.Data]:
+				flush()
+				walk(c)
+				flush()
+			default:
+				walk(c)
+			}
+		}
+	}
+	for _, n := range cell.Nodes {
+		walk(n)
+	}
+	flush()
+	return lines
+}
+
+var (
+	groupRe   = regexp.MustCompile(`^\d+$`)
+	classRe   = regexp.MustCompile(`^[\p{L}\p{N}]+`)
+	lessonsRe = regexp.MustCompile(`\d+\s*/\s*\d+`)
+)
+
+// structuredSubjects builds subjects from cell lines, reporting false unless
+// every subject has a name, a numeric group and periods that parse.
+func structuredSubjects(lines []string) ([]Subject, bool) {
+	if len(lines) < 2 {
+		return nil, false
+	}
+	var subjects []Subject
+	for _, line := range lines {
+		line = strings.Trim(line, "- ")
+		field := ""
+		label, value, ok := strings.Cut(line, ":")
+		if ok {
+			field = cellField(label)
+			value = strings.Trim(value, "- ")
+		}
+		if field == "" || field == "name" {
+			if field == "name" {
+				line = value
+			}
+			sub := Subject{Name: line}
+			if m := nameCodeRe.FindStringSubmatch(line); m != nil {
+				sub.Name, sub.Code = m[1], m[2]
+			}
+			subjects = append(subjects, sub)
+			continue
+		}
+		if len(subjects) == 0 {
+			return nil, false
+		}
+		sub := &subjects[len(subjects)-1]
+		switch field {
+		case "group":
+			sub.Group = value
+		case "class":
+			sub.Class = NormalizeClassCode(classRe.FindString(value))
+		case "period":
+			sub.Period = value
+		case "room":
+			sub.Room = value
+		case "teacher":
+			sub.Teacher = value
+		case "lessons":
+			sub.Lessons = value
+			if m := lessonsRe.FindString(value); m != "" {
+				sub.Lessons = strings.ReplaceAll(m, " ", "")
+			}
+		}
+	}
+	for i := range subjects {
+		sub := &subjects[i]
+		sub.setPeriods()
+		if sub.Name == "" || !groupRe.MatchString(sub.Group) || len(sub.Periods) == 0 {
+			return nil, false
+		}
+		sub.setProgress()
+		sub.setSectionLabel()
+	}
+	return subjects, true
+}
+
+// cellField maps a cell line's label to the Subject field it holds, using
+// the ListParser column labels but matching them whole.
+func cellField(label string) string {
+	label = strings.TrimSpace(strings.ReplaceAll(strings.ToLower(FoldDiacritics(label)), "đ", "d"))
+	for _, c := range listColumns {
+		if label == c.label {
+			return c.field
+		}
+	}
+	return ""
+}
+
+// headerSlots reads the slot labels from the table header so columns are
+// mapped by name rather than position. Cells that aren't slot labels (such as
+// the day column's corner cell) are skipped.
+func (p *HTMLParser) headerSlots(row *goquery.Selection) []string {
+	var slots []string
+	row.FindMatcher(p.labels).Each(func(_ int, cell *goquery.Selection) {
+		label := strings.ToLower(norm.NFC.String(strings.TrimSpace(cell.Text())))
+		for _, slot := range Slots {
+			if strings.Contains(label, strings.ToLower(slot)) {
+				slots = append(slots, slot)
+				return
+			}
+		}
+	})
+	return slots
+}
+
+func (d *DaySchedule) set(slot string, subjects []Subject) {
+	switch slot {
+	case "Sáng":
+		d.Sang = subjects
+	case "Chiều":
+		d.Chieu = subjects
+	case "Tối":
+		d.Toi = subjects
+	}
+}
@@ -0,0 +1,134 @@
+package dluparser
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func parseFixture(t *testing.T, p Parser, name string) Schedule {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := p.Parse(f)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return s
+}
+
+func names(subjects []Subject) []string {
+	out := []string{}
+	for _, s := range subjects {
+		out = append(out, s.Name)
+	}
+	return out
+}
+
+func TestHTMLParserSlotColumns(t *testing.T) {
+	p, err := NewHTMLParser(DefaultSelectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both fixtures hold the same Monday; one labels its columns in a
+	// different order, the other doesn't label them at all.
+	for _, fixture := range []string{"reordered.html", "positional.html"} {
+		s := parseFixture(t, p, fixture)
+		if s.Class != "CTK45" || s.Week != "3" {
+			t.Errorf("%s: class %q week %q, want CTK45 week 3", fixture, s.Class, s.Week)
+		}
+		day, ok := s.Days["Thứ 2"]
+		if !ok {
+			t.Fatalf("%s: no Thứ 2 in %v", fixture, s.Days)
+		}
+		for _, tt := range []struct {
+			slot     string
+			subjects []Subject
+			want     string
+		}{
+			{"Sáng", day.Sang, "Lập trình Go"},
+			{"Chiều", day.Chieu, "Cơ sở dữ liệu"},
+			{"Tối", day.Toi, "Triết học"},
+		} {
+			if got := names(tt.subjects); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("%s: %s = %v, want [%s]", fixture, tt.slot, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestHTMLParserCellStructure(t *testing.T) {
+	// The hyphenated room and teacher defeat the text regex, which drops the
+	// subject; read from the cell's <br> lines it comes through whole.
+	flat := "Thực hành mạng (INF305)- Nhóm: 1- Lớp: CTK45- Tiết: 1-3 - Phòng: A1-101 - GV: Nguyễn Lê-Anh - Đã học: 3/45"
+	if got := ParseSubjects(flat); len(got) != 0 {
+		t.Fatalf("ParseSubjects(%q) = %v, expected the regex to miss it", flat, got)
+	}
+
+	p, err := NewHTMLParser(DefaultSelectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := parseFixture(t, p, "structured.html").Days["Thứ 2"]
+	want := []Subject{
+		{Name: "Thực hành mạng", Code: "INF305", Group: "1", Class: "CTK45", Period: "1-3", Room: "A1-101", Teacher: "Nguyễn Lê-Anh", Lessons: "3/45", SectionLabel: "INF305 - Nhóm 1"},
+		{Name: "Lập trình Go", Code: "INF123", Group: "2", Class: "CTK45", Period: "4-5", Room: "B2.202", Teacher: "Trần Thị B", Lessons: "6/30", SectionLabel: "INF123 - Nhóm 2"},
+	}
+	if len(day.Sang) != len(want) {
+		t.Fatalf("Sáng = %v, want %d subjects", names(day.Sang), len(want))
+	}
+	for i, w := range want {
+		got := day.Sang[i]
+		got.LessonsDone, got.LessonsTotal, got.LessonsRemaining, got.ProgressPercent = 0, 0, 0, 0
+		got.PeriodStart, got.PeriodEnd, got.Periods = 0, 0, nil
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("Sáng[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if s := day.Sang[0]; s.LessonsDone != 3 || s.LessonsTotal != 45 || s.PeriodEnd != 3 {
+		t.Errorf("derived fields not set: %+v", s)
+	}
+	// A cell in the one-line text format still goes through ParseSubjects.
+	if got := names(day.Chieu); len(got) != 1 || got[0] != "Cơ sở dữ liệu" {
+		t.Errorf("Chiều = %v, want [Cơ sở dữ liệu]", got)
+	}
+	if day.Toi != nil {
+		t.Errorf("Tối = %v, want nil for Nghỉ", names(day.Toi))
+	}
+}
+
+func TestHTMLParserMixedCase(t *testing.T) {
+	p, err := NewHTMLParser(DefaultSelectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The header and day cells are in the wrong case, and one day is
+	// spelled out in words.
+	s := parseFixture(t, p, "mixed_case.html")
+	if s.Class != "CTK45" || s.Week != "3" {
+		t.Errorf("class %q week %q, want CTK45 week 3", s.Class, s.Week)
+	}
+	days := s.OrderedDays()
+	want := []struct {
+		weekday, name, subject string
+		slot                   func(DaySchedule) []Subject
+	}{
+		{"Monday", "Thứ 2", "Lập trình Go", func(d DaySchedule) []Subject { return d.Sang }},
+		{"Wednesday", "Thứ 4", "Cơ sở dữ liệu", func(d DaySchedule) []Subject { return d.Chieu }},
+	}
+	if len(days) != len(want) {
+		t.Fatalf("got %d days, want %d: %v", len(days), len(want), s.Days)
+	}
+	for i, w := range want {
+		d := days[i]
+		if d.Weekday != w.weekday || d.VietnameseName != w.name {
+			t.Errorf("day %d = %s (%s), want %s (%s)", i, d.Weekday, d.VietnameseName, w.weekday, w.name)
+		}
+		if got := names(w.slot(d.DaySchedule)); len(got) != 1 || got[0] != w.subject {
+			t.Errorf("%s: subjects %v, want [%s]", w.name, got, w.subject)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package dluparser
+
+import (
+	"io"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Option is one entry of a dropdown on the portal, such as a week in the
+// week picker.
+type Option struct {
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Selected bool   `json:"selected,omitempty"`
+	// Group is the label of the <optgroup> the option sits in, if any.
+	Group string `json:"group,omitempty"`
+}
+
+// ParseSelects reads every <select> on a page, keyed by its lower-cased name
+// (or id, when it has no name). Options without a value, typically a
+// "-- chọn --" placeholder, are skipped.
+func ParseSelects(r io.Reader) (map[string][]Option, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	selects := make(map[string][]Option)
+	doc.Find("select").Each(func(_ int, sel *goquery.Selection) {
+		key := sel.AttrOr("name", sel.AttrOr("id", ""))
+		if key == "" {
+			return
+		}
+		key = strings.ToLower(key)
+		var opts []Option
+		sel.Find("option").Each(func(_ int, o *goquery.Selection) {
+			label := strings.Join(strings.Fields(norm.NFC.String(o.Text())), " ")
+			value := strings.TrimSpace(o.AttrOr("value", label))
+			if value == "" {
+				return
+			}
+			_, selected := o.Attr("selected")
+			group := strings.Join(strings.Fields(norm.NFC.String(o.Closest("optgroup").AttrOr("label", ""))), " ")
+			opts = append(opts, Option{Value: value, Label: label, Selected: selected, Group: group})
+		})
+		if _, seen := selects[key]; !seen {
+			selects[key] = opts
+		}
+	})
+	return selects, nil
+}
@@ -0,0 +1,96 @@
+package dluparser
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxPeriod is the highest period a timetable can name. No university day
+// has more, and the bound keeps a malformed "1-50000000" from expanding.
+const MaxPeriod = 16
+
+// ParsePeriods expands a "Tiết" value into the sorted, de-duplicated periods
+// it covers. Ranges ("1-3"), comma lists ("1,2,5") and mixes ("1-3,7") are
+// accepted; nil is returned if any part isn't a number or range of periods
+// 1 to MaxPeriod.
+func ParsePeriods(period string) []int {
+	var periods []int
+	for _, part := range strings.Split(period, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return nil
+			}
+		}
+		if end < start {
+			start, end = end, start
+		}
+		if start < 1 || end > MaxPeriod {
+			return nil
+		}
+		for p := start; p <= end; p++ {
+			periods = append(periods, p)
+		}
+	}
+	slices.Sort(periods)
+	return slices.Compact(periods)
+}
+
+func (s *Subject) setPeriods() {
+	s.Periods = ParsePeriods(s.Period)
+	if len(s.Periods) > 0 {
+		s.PeriodStart = s.Periods[0]
+		s.PeriodEnd = s.Periods[len(s.Periods)-1]
+	}
+}
+
+// setSectionLabel joins Code and Group as "INF123 - Nhóm 1", leaving out
+// whichever the page didn't give.
+func (s *Subject) setSectionLabel() {
+	var parts []string
+	if s.Code != "" {
+		parts = append(parts, s.Code)
+	}
+	if s.Group != "" {
+		parts = append(parts, "Nhóm "+s.Group)
+	}
+	s.SectionLabel = strings.Join(parts, " - ")
+}
+
+// setProgress splits "Đã học" ("10/15") into lessons done, total and
+// remaining, with the percentage rounded to one decimal.
+func (s *Subject) setProgress() {
+	done, total, ok := strings.Cut(s.Lessons, "/")
+	if !ok {
+		return
+	}
+	s.LessonsDone, _ = strconv.Atoi(strings.TrimSpace(done))
+	s.LessonsTotal, _ = strconv.Atoi(strings.TrimSpace(total))
+	if s.LessonsTotal > 0 {
+		s.ProgressPercent = math.Round(float64(s.LessonsDone)*1000/float64(s.LessonsTotal)) / 10
+		s.LessonsRemaining = max(s.LessonsTotal-s.LessonsDone, 0)
+	}
+}
+
+// HasProgress reports whether Lessons parsed as "done/total" with a
+// non-zero total, i.e. whether the lesson counts mean anything.
+func (s Subject) HasProgress() bool {
+	done, total, ok := strings.Cut(s.Lessons, "/")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimSpace(done))
+	n, err2 := strconv.Atoi(strings.TrimSpace(total))
+	return err == nil && err2 == nil && n > 0
+}
@@ -0,0 +1,121 @@
+package dluparser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePeriods(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"3", []int{3}},
+		{"1-3", []int{1, 2, 3}},
+		{" 7 - 9 ", []int{7, 8, 9}},
+		{"3-1", []int{1, 2, 3}},
+		{"1,2,5", []int{1, 2, 5}},
+		{"5, 1 ,2", []int{1, 2, 5}},
+		{"1-3,7", []int{1, 2, 3, 7}},
+		{"1-3,2-4", []int{1, 2, 3, 4}},
+		{"1,,2,", []int{1, 2}},
+		{"16", []int{16}},
+
+		{"", nil},
+		{"abc", nil},
+		{"1-x", nil},
+		{"1-3,x", nil},
+		{"0", nil},
+		{"17", nil},
+		{"-1", nil},
+		{"1-50000000", nil},
+	}
+	for _, tt := range tests {
+		if got := ParsePeriods(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("ParsePeriods(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSubjectPeriodBounds(t *testing.T) {
+	tests := []struct {
+		period     string
+		start, end int
+	}{
+		{"1-3", 1, 3},
+		{"1,2,5", 1, 5},
+		{"7,1-3", 1, 7},
+		{"1-99", 0, 0},
+	}
+	for _, tt := range tests {
+		line := "Lập trình Go- Nhóm: 1- Lớp: CTK45- Tiết: " + tt.period + " - Phòng: A1.101 - GV: Nguyễn Văn A - Đã học: 3/45"
+		subjects := ParseSubjects(line)
+		if len(subjects) != 1 {
+			t.Fatalf("ParseSubjects(%q) returned %d subjects, want 1", line, len(subjects))
+		}
+		s := subjects[0]
+		if s.Period != tt.period || s.PeriodStart != tt.start || s.PeriodEnd != tt.end {
+			t.Errorf("period %q: got Period %q, start %d, end %d; want start %d, end %d", tt.period, s.Period, s.PeriodStart, s.PeriodEnd, tt.start, tt.end)
+		}
+	}
+}
+
+func TestSectionLabel(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"Lập trình web(CT3101.1)- Nhóm: 2- Lớp: CTK45- Tiết: 1-3 - Phòng: A1.101 - GV: Nguyễn Văn A - Đã học: 3/45", "CT3101.1 - Nhóm 2"},
+		{"Lập trình Go- Nhóm: 1- Lớp: CTK45- Tiết: 1-3 - Phòng: A1.101 - GV: Nguyễn Văn A - Đã học: 3/45", "Nhóm 1"},
+	}
+	for _, tt := range tests {
+		subjects := ParseSubjects(tt.line)
+		if len(subjects) != 1 {
+			t.Fatalf("ParseSubjects(%q) returned %d subjects, want 1", tt.line, len(subjects))
+		}
+		if got := subjects[0].SectionLabel; got != tt.want {
+			t.Errorf("SectionLabel = %q, want %q", got, tt.want)
+		}
+	}
+
+	// The group is always present in the text format, so a code-only and an
+	// empty label come from Subjects built by hand.
+	for _, tt := range []struct {
+		sub  Subject
+		want string
+	}{
+		{Subject{Code: "INF123"}, "INF123"},
+		{Subject{}, ""},
+	} {
+		tt.sub.setSectionLabel()
+		if tt.sub.SectionLabel != tt.want {
+			t.Errorf("setSectionLabel(%+v) = %q, want %q", tt.sub, tt.sub.SectionLabel, tt.want)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	tests := []struct {
+		lessons     string
+		done, total int
+		remaining   int
+		percent     float64
+		hasProgress bool
+	}{
+		{"10/15", 10, 15, 5, 66.7, true},
+		{"45/45", 45, 45, 0, 100, true},
+		{"50/45", 50, 45, 0, 111.1, true},
+		{"0/0", 0, 0, 0, 0, false},
+		{"chưa cập nhật", 0, 0, 0, 0, false},
+		{"", 0, 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		s := Subject{Lessons: tt.lessons}
+		s.setProgress()
+		if s.LessonsDone != tt.done || s.LessonsTotal != tt.total || s.LessonsRemaining != tt.remaining || s.ProgressPercent != tt.percent {
+			t.Errorf("%q: done %d total %d remaining %d percent %v; want %d %d %d %v", tt.lessons, s.LessonsDone, s.LessonsTotal, s.LessonsRemaining, s.ProgressPercent, tt.done, tt.total, tt.remaining, tt.percent)
+		}
+		if s.HasProgress() != tt.hasProgress {
+			t.Errorf("%q: HasProgress = %v, want %v", tt.lessons, s.HasProgress(), tt.hasProgress)
+		}
+	}
+}
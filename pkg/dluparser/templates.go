@@ -0,0 +1,200 @@
+package dluparser
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Parser reads a timetable page in one of the portal's layouts.
+// *HTMLParser handles DrawingClassStudentSchedules_Mau2.
+type Parser interface {
+	Parse(r io.Reader) (Schedule, error)
+}
+
+// cellText is a cell's text with whitespace collapsed, in NFC.
+func cellText(s *goquery.Selection) string {
+	return norm.NFC.String(strings.Join(strings.Fields(s.Text()), " "))
+}
+
+// findHeader returns the innermost element text naming the week and class,
+// for layouts that don't put it in a fixed place.
+func findHeader(doc *goquery.Document) (week, className string) {
+	best := ""
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		text := cellText(s)
+		lower := strings.ToLower(text)
+		if strings.Contains(lower, "tuần") && strings.Contains(lower, "lớp:") && (best == "" || len(text) < len(best)) {
+			best = text
+		}
+	})
+	return ParseHeader(best)
+}
+
+// slotOf matches a session label such as "Buổi sáng" to one of Slots.
+func slotOf(label string) (string, bool) {
+	label = strings.ToLower(label)
+	for _, slot := range Slots {
+		if strings.Contains(label, strings.ToLower(slot)) {
+			return slot, true
+		}
+	}
+	return "", false
+}
+
+// DayColumnsParser reads the DrawingClassStudentSchedules_Mau1 layout, the
+// Mau2 grid turned on its side: a column per day and a row per session.
+type DayColumnsParser struct{}
+
+func (DayColumnsParser) Parse(r io.Reader) (Schedule, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Schedule{}, err
+	}
+	week, className := findHeader(doc)
+	days := make(map[string]DaySchedule)
+
+	var columns map[int]string
+	doc.Find("table tr").Each(func(_ int, row *goquery.Selection) {
+		cells := row.Find("th, td")
+		if columns == nil {
+			found := make(map[int]string)
+			cells.Each(func(j int, cell *goquery.Selection) {
+				if name := cellText(cell); name != "" {
+					if _, ok := WeekdayIndex(name); ok {
+						found[j] = name
+					}
+				}
+			})
+			if len(found) >= 2 {
+				columns = found
+			}
+			return
+		}
+		slot, ok := slotOf(cellText(cells.First()))
+		if !ok {
+			return
+		}
+		cells.Each(func(j int, cell *goquery.Selection) {
+			day, ok := columns[j]
+			if !ok {
+				return
+			}
+			ds := days[day]
+			if cellText(cell) != "" {
+				var add DaySchedule
+				add.set(slot, cellSubjects(cell))
+				ds = MergeDays(ds, add)
+			}
+			days[day] = ds
+		})
+	})
+	return Schedule{Class: className, Week: week, Days: days}, nil
+}
+
+// listColumns maps ListParser header labels to what the column holds. Labels
+// are matched lower-case and accent-free, longest first where they overlap.
+var listColumns = []struct{ label, field string }{
+	{"giang vien", "teacher"},
+	{"da hoc", "lessons"},
+	{"thu", "day"},
+	{"buoi", "slot"},
+	{"mon", "name"},
+	{"nhom", "group"},
+	{"lop", "class"},
+	{"tiet", "period"},
+	{"phong", "room"},
+	{"gv", "teacher"},
+}
+
+var nameCodeRe = regexp.MustCompile(`^(.*?)\s*\(([A-Za-z0-9][A-Za-z0-9.\-]*\d[A-Za-z0-9.\-]*)\)$`)
+
+// ListParser reads the DrawingClassStudentSchedules_Mau3 layout: one row
+// per session under a header naming each column (Thứ, Buổi, Môn, Nhóm, Lớp,
+// Tiết, Phòng, Giảng viên, Đã học). Columns it doesn't recognize are
+// treated as timetable text in the Mau2 cell format.
+type ListParser struct{}
+
+func (ListParser) Parse(r io.Reader) (Schedule, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Schedule{}, err
+	}
+	week, className := findHeader(doc)
+	days := make(map[string]DaySchedule)
+
+	var fields map[int]string
+	doc.Find("table tr").Each(func(_ int, row *goquery.Selection) {
+		cells := row.Find("th, td")
+		if fields == nil {
+			found := make(map[int]string)
+			has := make(map[string]bool)
+			cells.Each(func(j int, cell *goquery.Selection) {
+				label := strings.ReplaceAll(strings.ToLower(FoldDiacritics(cellText(cell))), "đ", "d")
+				found[j] = "text"
+				for _, c := range listColumns {
+					if strings.Contains(label, c.label) {
+						found[j] = c.field
+						break
+					}
+				}
+				has[found[j]] = true
+			})
+			if has["day"] && has["slot"] {
+				fields = found
+			}
+			return
+		}
+
+		var day, slot, text string
+		var sub Subject
+		cells.Each(func(j int, cell *goquery.Selection) {
+			v := cellText(cell)
+			switch fields[j] {
+			case "day":
+				day = v
+			case "slot":
+				slot, _ = slotOf(v)
+			case "name":
+				sub.Name = v
+				if m := nameCodeRe.FindStringSubmatch(v); m != nil {
+					sub.Name, sub.Code = m[1], m[2]
+				}
+			case "group":
+				sub.Group = v
+			case "class":
+				sub.Class = NormalizeClassCode(v)
+			case "period":
+				sub.Period = v
+			case "room":
+				sub.Room = v
+			case "teacher":
+				sub.Teacher = v
+			case "lessons":
+				sub.Lessons = v
+			default:
+				text = strings.TrimSpace(text + " " + v)
+			}
+		})
+		if day == "" || slot == "" {
+			return
+		}
+
+		var subjects []Subject
+		if sub.Name != "" {
+			sub.setPeriods()
+			sub.setProgress()
+			sub.setSectionLabel()
+			subjects = []Subject{sub}
+		} else if text != "" {
+			subjects = ParseSubjects(text)
+		}
+		var add DaySchedule
+		add.set(slot, subjects)
+		days[day] = MergeDays(days[day], add)
+	})
+	return Schedule{Class: className, Week: week, Days: days}, nil
+}
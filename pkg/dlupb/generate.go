@@ -0,0 +1,5 @@
+// Package dlupb holds the protobuf messages and gRPC stubs for dlu-api's
+// ScheduleService, generated from schedule.proto.
+package dlupb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative schedule.proto
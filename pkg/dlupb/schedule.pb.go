@@ -0,0 +1,1191 @@
+// Schedules as served by dlu-api's ScheduleService, the gRPC counterpart of
+// the /dlu HTTP endpoints. Field names follow the JSON API's English names;
+// the Vietnamese JSON key is noted where it differs.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: schedule.proto
+
+package dlupb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Subject is one class session in a slot.
+type Subject struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`       // ten_mon
+	Code             string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`       // ma_mon
+	Group            string                 `protobuf:"bytes,3,opt,name=group,proto3" json:"group,omitempty"`     // nhom
+	Class            string                 `protobuf:"bytes,4,opt,name=class,proto3" json:"class,omitempty"`     // lop
+	Period           string                 `protobuf:"bytes,5,opt,name=period,proto3" json:"period,omitempty"`   // tiet, as published, e.g. "1-3"
+	Room             string                 `protobuf:"bytes,6,opt,name=room,proto3" json:"room,omitempty"`       // phong
+	Teacher          string                 `protobuf:"bytes,7,opt,name=teacher,proto3" json:"teacher,omitempty"` // gv
+	Lessons          string                 `protobuf:"bytes,8,opt,name=lessons,proto3" json:"lessons,omitempty"` // da_hoc, as published, e.g. "6/45"
+	LessonsDone      int32                  `protobuf:"varint,9,opt,name=lessons_done,json=lessonsDone,proto3" json:"lessons_done,omitempty"`
+	LessonsTotal     int32                  `protobuf:"varint,10,opt,name=lessons_total,json=lessonsTotal,proto3" json:"lessons_total,omitempty"`
+	LessonsRemaining int32                  `protobuf:"varint,11,opt,name=lessons_remaining,json=lessonsRemaining,proto3" json:"lessons_remaining,omitempty"`
+	ProgressPercent  float64                `protobuf:"fixed64,12,opt,name=progress_percent,json=progressPercent,proto3" json:"progress_percent,omitempty"`
+	SectionLabel     string                 `protobuf:"bytes,13,opt,name=section_label,json=sectionLabel,proto3" json:"section_label,omitempty"` // lop_hoc_phan
+	PeriodStart      int32                  `protobuf:"varint,14,opt,name=period_start,json=periodStart,proto3" json:"period_start,omitempty"`
+	PeriodEnd        int32                  `protobuf:"varint,15,opt,name=period_end,json=periodEnd,proto3" json:"period_end,omitempty"`
+	Periods          []int32                `protobuf:"varint,16,rep,packed,name=periods,proto3" json:"periods,omitempty"`
+	StartTime        string                 `protobuf:"bytes,17,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // "07:00"
+	EndTime          string                 `protobuf:"bytes,18,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Subject) Reset() {
+	*x = Subject{}
+	mi := &file_schedule_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subject) ProtoMessage() {}
+
+func (x *Subject) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subject.ProtoReflect.Descriptor instead.
+func (*Subject) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Subject) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Subject) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Subject) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Subject) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+func (x *Subject) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *Subject) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Subject) GetTeacher() string {
+	if x != nil {
+		return x.Teacher
+	}
+	return ""
+}
+
+func (x *Subject) GetLessons() string {
+	if x != nil {
+		return x.Lessons
+	}
+	return ""
+}
+
+func (x *Subject) GetLessonsDone() int32 {
+	if x != nil {
+		return x.LessonsDone
+	}
+	return 0
+}
+
+func (x *Subject) GetLessonsTotal() int32 {
+	if x != nil {
+		return x.LessonsTotal
+	}
+	return 0
+}
+
+func (x *Subject) GetLessonsRemaining() int32 {
+	if x != nil {
+		return x.LessonsRemaining
+	}
+	return 0
+}
+
+func (x *Subject) GetProgressPercent() float64 {
+	if x != nil {
+		return x.ProgressPercent
+	}
+	return 0
+}
+
+func (x *Subject) GetSectionLabel() string {
+	if x != nil {
+		return x.SectionLabel
+	}
+	return ""
+}
+
+func (x *Subject) GetPeriodStart() int32 {
+	if x != nil {
+		return x.PeriodStart
+	}
+	return 0
+}
+
+func (x *Subject) GetPeriodEnd() int32 {
+	if x != nil {
+		return x.PeriodEnd
+	}
+	return 0
+}
+
+func (x *Subject) GetPeriods() []int32 {
+	if x != nil {
+		return x.Periods
+	}
+	return nil
+}
+
+func (x *Subject) GetStartTime() string {
+	if x != nil {
+		return x.StartTime
+	}
+	return ""
+}
+
+func (x *Subject) GetEndTime() string {
+	if x != nil {
+		return x.EndTime
+	}
+	return ""
+}
+
+// Day is one day of the week, Monday first.
+type Day struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Weekday        string                 `protobuf:"bytes,1,opt,name=weekday,proto3" json:"weekday,omitempty"`                                     // "Monday"
+	VietnameseName string                 `protobuf:"bytes,2,opt,name=vietnamese_name,json=vietnameseName,proto3" json:"vietnamese_name,omitempty"` // "Thứ 2"
+	Date           string                 `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`                                           // "2025-09-08", when the week is known
+	Morning        []*Subject             `protobuf:"bytes,4,rep,name=morning,proto3" json:"morning,omitempty"`                                     // sang
+	Afternoon      []*Subject             `protobuf:"bytes,5,rep,name=afternoon,proto3" json:"afternoon,omitempty"`                                 // chieu
+	Evening        []*Subject             `protobuf:"bytes,6,rep,name=evening,proto3" json:"evening,omitempty"`                                     // toi
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Day) Reset() {
+	*x = Day{}
+	mi := &file_schedule_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Day) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Day) ProtoMessage() {}
+
+func (x *Day) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Day.ProtoReflect.Descriptor instead.
+func (*Day) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Day) GetWeekday() string {
+	if x != nil {
+		return x.Weekday
+	}
+	return ""
+}
+
+func (x *Day) GetVietnameseName() string {
+	if x != nil {
+		return x.VietnameseName
+	}
+	return ""
+}
+
+func (x *Day) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *Day) GetMorning() []*Subject {
+	if x != nil {
+		return x.Morning
+	}
+	return nil
+}
+
+func (x *Day) GetAfternoon() []*Subject {
+	if x != nil {
+		return x.Afternoon
+	}
+	return nil
+}
+
+func (x *Day) GetEvening() []*Subject {
+	if x != nil {
+		return x.Evening
+	}
+	return nil
+}
+
+type Meta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	YearStudy     string                 `protobuf:"bytes,1,opt,name=year_study,json=yearStudy,proto3" json:"year_study,omitempty"`
+	TermId        string                 `protobuf:"bytes,2,opt,name=term_id,json=termId,proto3" json:"term_id,omitempty"`
+	Semester      string                 `protobuf:"bytes,3,opt,name=semester,proto3" json:"semester,omitempty"`
+	Checksum      string                 `protobuf:"bytes,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Provider      string                 `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	Template      string                 `protobuf:"bytes,6,opt,name=template,proto3" json:"template,omitempty"`
+	Attempts      int32                  `protobuf:"varint,7,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	FetchedAt     string                 `protobuf:"bytes,8,opt,name=fetched_at,json=fetchedAt,proto3" json:"fetched_at,omitempty"`
+	Stale         bool                   `protobuf:"varint,9,opt,name=stale,proto3" json:"stale,omitempty"`
+	Cache         string                 `protobuf:"bytes,10,opt,name=cache,proto3" json:"cache,omitempty"` // "hit", "miss" or "stale"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Meta) Reset() {
+	*x = Meta{}
+	mi := &file_schedule_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Meta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Meta) ProtoMessage() {}
+
+func (x *Meta) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Meta.ProtoReflect.Descriptor instead.
+func (*Meta) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Meta) GetYearStudy() string {
+	if x != nil {
+		return x.YearStudy
+	}
+	return ""
+}
+
+func (x *Meta) GetTermId() string {
+	if x != nil {
+		return x.TermId
+	}
+	return ""
+}
+
+func (x *Meta) GetSemester() string {
+	if x != nil {
+		return x.Semester
+	}
+	return ""
+}
+
+func (x *Meta) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *Meta) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Meta) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *Meta) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *Meta) GetFetchedAt() string {
+	if x != nil {
+		return x.FetchedAt
+	}
+	return ""
+}
+
+func (x *Meta) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *Meta) GetCache() string {
+	if x != nil {
+		return x.Cache
+	}
+	return ""
+}
+
+type Warning struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Warning) Reset() {
+	*x = Warning{}
+	mi := &file_schedule_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Warning) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Warning) ProtoMessage() {}
+
+func (x *Warning) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Warning.ProtoReflect.Descriptor instead.
+func (*Warning) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Warning) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Warning) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Schedule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Class         string                 `protobuf:"bytes,1,opt,name=class,proto3" json:"class,omitempty"`
+	Week          string                 `protobuf:"bytes,2,opt,name=week,proto3" json:"week,omitempty"`
+	Days          []*Day                 `protobuf:"bytes,3,rep,name=days,proto3" json:"days,omitempty"`
+	Meta          *Meta                  `protobuf:"bytes,4,opt,name=meta,proto3" json:"meta,omitempty"`
+	Warnings      []*Warning             `protobuf:"bytes,5,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schedule) Reset() {
+	*x = Schedule{}
+	mi := &file_schedule_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schedule) ProtoMessage() {}
+
+func (x *Schedule) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schedule.ProtoReflect.Descriptor instead.
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Schedule) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+func (x *Schedule) GetWeek() string {
+	if x != nil {
+		return x.Week
+	}
+	return ""
+}
+
+func (x *Schedule) GetDays() []*Day {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+func (x *Schedule) GetMeta() *Meta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *Schedule) GetWarnings() []*Warning {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+// WeekQuery picks a week the way /dlu's query parameters do: year_study and
+// term_id, or a semester label such as "HK1-2025", and a week number,
+// "current" or "next".
+type WeekQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	YearStudy     string                 `protobuf:"bytes,1,opt,name=year_study,json=yearStudy,proto3" json:"year_study,omitempty"`
+	TermId        string                 `protobuf:"bytes,2,opt,name=term_id,json=termId,proto3" json:"term_id,omitempty"`
+	Semester      string                 `protobuf:"bytes,3,opt,name=semester,proto3" json:"semester,omitempty"`
+	Week          string                 `protobuf:"bytes,4,opt,name=week,proto3" json:"week,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WeekQuery) Reset() {
+	*x = WeekQuery{}
+	mi := &file_schedule_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WeekQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WeekQuery) ProtoMessage() {}
+
+func (x *WeekQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WeekQuery.ProtoReflect.Descriptor instead.
+func (*WeekQuery) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WeekQuery) GetYearStudy() string {
+	if x != nil {
+		return x.YearStudy
+	}
+	return ""
+}
+
+func (x *WeekQuery) GetTermId() string {
+	if x != nil {
+		return x.TermId
+	}
+	return ""
+}
+
+func (x *WeekQuery) GetSemester() string {
+	if x != nil {
+		return x.Semester
+	}
+	return ""
+}
+
+func (x *WeekQuery) GetWeek() string {
+	if x != nil {
+		return x.Week
+	}
+	return ""
+}
+
+type GetScheduleRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Week           *WeekQuery             `protobuf:"bytes,1,opt,name=week,proto3" json:"week,omitempty"`
+	ClassStudentId string                 `protobuf:"bytes,2,opt,name=class_student_id,json=classStudentId,proto3" json:"class_student_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetScheduleRequest) Reset() {
+	*x = GetScheduleRequest{}
+	mi := &file_schedule_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetScheduleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetScheduleRequest) ProtoMessage() {}
+
+func (x *GetScheduleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetScheduleRequest.ProtoReflect.Descriptor instead.
+func (*GetScheduleRequest) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetScheduleRequest) GetWeek() *WeekQuery {
+	if x != nil {
+		return x.Week
+	}
+	return nil
+}
+
+func (x *GetScheduleRequest) GetClassStudentId() string {
+	if x != nil {
+		return x.ClassStudentId
+	}
+	return ""
+}
+
+type GetBatchRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Week            *WeekQuery             `protobuf:"bytes,1,opt,name=week,proto3" json:"week,omitempty"`
+	ClassStudentIds []string               `protobuf:"bytes,2,rep,name=class_student_ids,json=classStudentIds,proto3" json:"class_student_ids,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetBatchRequest) Reset() {
+	*x = GetBatchRequest{}
+	mi := &file_schedule_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchRequest) ProtoMessage() {}
+
+func (x *GetBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchRequest.ProtoReflect.Descriptor instead.
+func (*GetBatchRequest) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetBatchRequest) GetWeek() *WeekQuery {
+	if x != nil {
+		return x.Week
+	}
+	return nil
+}
+
+func (x *GetBatchRequest) GetClassStudentIds() []string {
+	if x != nil {
+		return x.ClassStudentIds
+	}
+	return nil
+}
+
+type GetBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedules     map[string]*Schedule   `protobuf:"bytes,1,rep,name=schedules,proto3" json:"schedules,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Errors        map[string]string      `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBatchResponse) Reset() {
+	*x = GetBatchResponse{}
+	mi := &file_schedule_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchResponse) ProtoMessage() {}
+
+func (x *GetBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchResponse.ProtoReflect.Descriptor instead.
+func (*GetBatchResponse) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetBatchResponse) GetSchedules() map[string]*Schedule {
+	if x != nil {
+		return x.Schedules
+	}
+	return nil
+}
+
+func (x *GetBatchResponse) GetErrors() map[string]string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+// StreamChangesRequest watches a week for several classes. A week of
+// "current" follows the current week, moving on as each new one starts.
+type StreamChangesRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Week            *WeekQuery             `protobuf:"bytes,1,opt,name=week,proto3" json:"week,omitempty"`
+	ClassStudentIds []string               `protobuf:"bytes,2,rep,name=class_student_ids,json=classStudentIds,proto3" json:"class_student_ids,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamChangesRequest) Reset() {
+	*x = StreamChangesRequest{}
+	mi := &file_schedule_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamChangesRequest) ProtoMessage() {}
+
+func (x *StreamChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamChangesRequest.ProtoReflect.Descriptor instead.
+func (*StreamChangesRequest) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamChangesRequest) GetWeek() *WeekQuery {
+	if x != nil {
+		return x.Week
+	}
+	return nil
+}
+
+func (x *StreamChangesRequest) GetClassStudentIds() []string {
+	if x != nil {
+		return x.ClassStudentIds
+	}
+	return nil
+}
+
+// Change is one session added, removed or modified.
+type Change struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "added", "removed" or "modified"
+	Day           string                 `protobuf:"bytes,2,opt,name=day,proto3" json:"day,omitempty"`
+	Slot          string                 `protobuf:"bytes,3,opt,name=slot,proto3" json:"slot,omitempty"`
+	Before        *Subject               `protobuf:"bytes,4,opt,name=before,proto3" json:"before,omitempty"`
+	After         *Subject               `protobuf:"bytes,5,opt,name=after,proto3" json:"after,omitempty"`
+	Fields        []string               `protobuf:"bytes,6,rep,name=fields,proto3" json:"fields,omitempty"` // tiet, phong, gv
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Change) Reset() {
+	*x = Change{}
+	mi := &file_schedule_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Change) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Change) ProtoMessage() {}
+
+func (x *Change) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Change.ProtoReflect.Descriptor instead.
+func (*Change) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Change) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Change) GetDay() string {
+	if x != nil {
+		return x.Day
+	}
+	return ""
+}
+
+func (x *Change) GetSlot() string {
+	if x != nil {
+		return x.Slot
+	}
+	return ""
+}
+
+func (x *Change) GetBefore() *Subject {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *Change) GetAfter() *Subject {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+func (x *Change) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+// ScheduleChange is sent once per class on connecting ("ready", with the
+// current checksum), whenever the poller finds a watched week changed, and
+// when a followed class moves on to a new week.
+type ScheduleChange struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Event          string                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"` // "ready", "schedule.changed" or "week.rollover"
+	ClassStudentId string                 `protobuf:"bytes,2,opt,name=class_student_id,json=classStudentId,proto3" json:"class_student_id,omitempty"`
+	YearStudy      string                 `protobuf:"bytes,3,opt,name=year_study,json=yearStudy,proto3" json:"year_study,omitempty"`
+	TermId         string                 `protobuf:"bytes,4,opt,name=term_id,json=termId,proto3" json:"term_id,omitempty"`
+	Week           string                 `protobuf:"bytes,5,opt,name=week,proto3" json:"week,omitempty"`
+	Checksum       string                 `protobuf:"bytes,6,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Changes        []*Change              `protobuf:"bytes,7,rep,name=changes,proto3" json:"changes,omitempty"`
+	Schedule       *Schedule              `protobuf:"bytes,8,opt,name=schedule,proto3" json:"schedule,omitempty"` // the new week's, on "week.rollover"
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ScheduleChange) Reset() {
+	*x = ScheduleChange{}
+	mi := &file_schedule_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleChange) ProtoMessage() {}
+
+func (x *ScheduleChange) ProtoReflect() protoreflect.Message {
+	mi := &file_schedule_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleChange.ProtoReflect.Descriptor instead.
+func (*ScheduleChange) Descriptor() ([]byte, []int) {
+	return file_schedule_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ScheduleChange) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *ScheduleChange) GetClassStudentId() string {
+	if x != nil {
+		return x.ClassStudentId
+	}
+	return ""
+}
+
+func (x *ScheduleChange) GetYearStudy() string {
+	if x != nil {
+		return x.YearStudy
+	}
+	return ""
+}
+
+func (x *ScheduleChange) GetTermId() string {
+	if x != nil {
+		return x.TermId
+	}
+	return ""
+}
+
+func (x *ScheduleChange) GetWeek() string {
+	if x != nil {
+		return x.Week
+	}
+	return ""
+}
+
+func (x *ScheduleChange) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *ScheduleChange) GetChanges() []*Change {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *ScheduleChange) GetSchedule() *Schedule {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+var File_schedule_proto protoreflect.FileDescriptor
+
+const file_schedule_proto_rawDesc = "" +
+	"\n" +
+	"\x0eschedule.proto\x12\x06dlu.v1\"\x98\x04\n" +
+	"\aSubject\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x14\n" +
+	"\x05group\x18\x03 \x01(\tR\x05group\x12\x14\n" +
+	"\x05class\x18\x04 \x01(\tR\x05class\x12\x16\n" +
+	"\x06period\x18\x05 \x01(\tR\x06period\x12\x12\n" +
+	"\x04room\x18\x06 \x01(\tR\x04room\x12\x18\n" +
+	"\ateacher\x18\a \x01(\tR\ateacher\x12\x18\n" +
+	"\alessons\x18\b \x01(\tR\alessons\x12!\n" +
+	"\flessons_done\x18\t \x01(\x05R\vlessonsDone\x12#\n" +
+	"\rlessons_total\x18\n" +
+	" \x01(\x05R\flessonsTotal\x12+\n" +
+	"\x11lessons_remaining\x18\v \x01(\x05R\x10lessonsRemaining\x12)\n" +
+	"\x10progress_percent\x18\f \x01(\x01R\x0fprogressPercent\x12#\n" +
+	"\rsection_label\x18\r \x01(\tR\fsectionLabel\x12!\n" +
+	"\fperiod_start\x18\x0e \x01(\x05R\vperiodStart\x12\x1d\n" +
+	"\n" +
+	"period_end\x18\x0f \x01(\x05R\tperiodEnd\x12\x18\n" +
+	"\aperiods\x18\x10 \x03(\x05R\aperiods\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x11 \x01(\tR\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x12 \x01(\tR\aendTime\"\xe1\x01\n" +
+	"\x03Day\x12\x18\n" +
+	"\aweekday\x18\x01 \x01(\tR\aweekday\x12'\n" +
+	"\x0fvietnamese_name\x18\x02 \x01(\tR\x0evietnameseName\x12\x12\n" +
+	"\x04date\x18\x03 \x01(\tR\x04date\x12)\n" +
+	"\amorning\x18\x04 \x03(\v2\x0f.dlu.v1.SubjectR\amorning\x12-\n" +
+	"\tafternoon\x18\x05 \x03(\v2\x0f.dlu.v1.SubjectR\tafternoon\x12)\n" +
+	"\aevening\x18\x06 \x03(\v2\x0f.dlu.v1.SubjectR\aevening\"\x95\x02\n" +
+	"\x04Meta\x12\x1d\n" +
+	"\n" +
+	"year_study\x18\x01 \x01(\tR\tyearStudy\x12\x17\n" +
+	"\aterm_id\x18\x02 \x01(\tR\x06termId\x12\x1a\n" +
+	"\bsemester\x18\x03 \x01(\tR\bsemester\x12\x1a\n" +
+	"\bchecksum\x18\x04 \x01(\tR\bchecksum\x12\x1a\n" +
+	"\bprovider\x18\x05 \x01(\tR\bprovider\x12\x1a\n" +
+	"\btemplate\x18\x06 \x01(\tR\btemplate\x12\x1a\n" +
+	"\battempts\x18\a \x01(\x05R\battempts\x12\x1d\n" +
+	"\n" +
+	"fetched_at\x18\b \x01(\tR\tfetchedAt\x12\x14\n" +
+	"\x05stale\x18\t \x01(\bR\x05stale\x12\x14\n" +
+	"\x05cache\x18\n" +
+	" \x01(\tR\x05cache\"7\n" +
+	"\aWarning\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xa4\x01\n" +
+	"\bSchedule\x12\x14\n" +
+	"\x05class\x18\x01 \x01(\tR\x05class\x12\x12\n" +
+	"\x04week\x18\x02 \x01(\tR\x04week\x12\x1f\n" +
+	"\x04days\x18\x03 \x03(\v2\v.dlu.v1.DayR\x04days\x12 \n" +
+	"\x04meta\x18\x04 \x01(\v2\f.dlu.v1.MetaR\x04meta\x12+\n" +
+	"\bwarnings\x18\x05 \x03(\v2\x0f.dlu.v1.WarningR\bwarnings\"s\n" +
+	"\tWeekQuery\x12\x1d\n" +
+	"\n" +
+	"year_study\x18\x01 \x01(\tR\tyearStudy\x12\x17\n" +
+	"\aterm_id\x18\x02 \x01(\tR\x06termId\x12\x1a\n" +
+	"\bsemester\x18\x03 \x01(\tR\bsemester\x12\x12\n" +
+	"\x04week\x18\x04 \x01(\tR\x04week\"e\n" +
+	"\x12GetScheduleRequest\x12%\n" +
+	"\x04week\x18\x01 \x01(\v2\x11.dlu.v1.WeekQueryR\x04week\x12(\n" +
+	"\x10class_student_id\x18\x02 \x01(\tR\x0eclassStudentId\"d\n" +
+	"\x0fGetBatchRequest\x12%\n" +
+	"\x04week\x18\x01 \x01(\v2\x11.dlu.v1.WeekQueryR\x04week\x12*\n" +
+	"\x11class_student_ids\x18\x02 \x03(\tR\x0fclassStudentIds\"\xa2\x02\n" +
+	"\x10GetBatchResponse\x12E\n" +
+	"\tschedules\x18\x01 \x03(\v2'.dlu.v1.GetBatchResponse.SchedulesEntryR\tschedules\x12<\n" +
+	"\x06errors\x18\x02 \x03(\v2$.dlu.v1.GetBatchResponse.ErrorsEntryR\x06errors\x1aN\n" +
+	"\x0eSchedulesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12&\n" +
+	"\x05value\x18\x02 \x01(\v2\x10.dlu.v1.ScheduleR\x05value:\x028\x01\x1a9\n" +
+	"\vErrorsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"i\n" +
+	"\x14StreamChangesRequest\x12%\n" +
+	"\x04week\x18\x01 \x01(\v2\x11.dlu.v1.WeekQueryR\x04week\x12*\n" +
+	"\x11class_student_ids\x18\x02 \x03(\tR\x0fclassStudentIds\"\xaa\x01\n" +
+	"\x06Change\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03day\x18\x02 \x01(\tR\x03day\x12\x12\n" +
+	"\x04slot\x18\x03 \x01(\tR\x04slot\x12'\n" +
+	"\x06before\x18\x04 \x01(\v2\x0f.dlu.v1.SubjectR\x06before\x12%\n" +
+	"\x05after\x18\x05 \x01(\v2\x0f.dlu.v1.SubjectR\x05after\x12\x16\n" +
+	"\x06fields\x18\x06 \x03(\tR\x06fields\"\x90\x02\n" +
+	"\x0eScheduleChange\x12\x14\n" +
+	"\x05event\x18\x01 \x01(\tR\x05event\x12(\n" +
+	"\x10class_student_id\x18\x02 \x01(\tR\x0eclassStudentId\x12\x1d\n" +
+	"\n" +
+	"year_study\x18\x03 \x01(\tR\tyearStudy\x12\x17\n" +
+	"\aterm_id\x18\x04 \x01(\tR\x06termId\x12\x12\n" +
+	"\x04week\x18\x05 \x01(\tR\x04week\x12\x1a\n" +
+	"\bchecksum\x18\x06 \x01(\tR\bchecksum\x12(\n" +
+	"\achanges\x18\a \x03(\v2\x0e.dlu.v1.ChangeR\achanges\x12,\n" +
+	"\bschedule\x18\b \x01(\v2\x10.dlu.v1.ScheduleR\bschedule2\xd6\x01\n" +
+	"\x0fScheduleService\x12;\n" +
+	"\vGetSchedule\x12\x1a.dlu.v1.GetScheduleRequest\x1a\x10.dlu.v1.Schedule\x12=\n" +
+	"\bGetBatch\x12\x17.dlu.v1.GetBatchRequest\x1a\x18.dlu.v1.GetBatchResponse\x12G\n" +
+	"\rStreamChanges\x12\x1c.dlu.v1.StreamChangesRequest\x1a\x16.dlu.v1.ScheduleChange0\x01B\x13Z\x11dlu-api/pkg/dlupbb\x06proto3"
+
+var (
+	file_schedule_proto_rawDescOnce sync.Once
+	file_schedule_proto_rawDescData []byte
+)
+
+func file_schedule_proto_rawDescGZIP() []byte {
+	file_schedule_proto_rawDescOnce.Do(func() {
+		file_schedule_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_schedule_proto_rawDesc), len(file_schedule_proto_rawDesc)))
+	})
+	return file_schedule_proto_rawDescData
+}
+
+var file_schedule_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_schedule_proto_goTypes = []any{
+	(*Subject)(nil),              // 0: dlu.v1.Subject
+	(*Day)(nil),                  // 1: dlu.v1.Day
+	(*Meta)(nil),                 // 2: dlu.v1.Meta
+	(*Warning)(nil),              // 3: dlu.v1.Warning
+	(*Schedule)(nil),             // 4: dlu.v1.Schedule
+	(*WeekQuery)(nil),            // 5: dlu.v1.WeekQuery
+	(*GetScheduleRequest)(nil),   // 6: dlu.v1.GetScheduleRequest
+	(*GetBatchRequest)(nil),      // 7: dlu.v1.GetBatchRequest
+	(*GetBatchResponse)(nil),     // 8: dlu.v1.GetBatchResponse
+	(*StreamChangesRequest)(nil), // 9: dlu.v1.StreamChangesRequest
+	(*Change)(nil),               // 10: dlu.v1.Change
+	(*ScheduleChange)(nil),       // 11: dlu.v1.ScheduleChange
+	nil,                          // 12: dlu.v1.GetBatchResponse.SchedulesEntry
+	nil,                          // 13: dlu.v1.GetBatchResponse.ErrorsEntry
+}
+var file_schedule_proto_depIdxs = []int32{
+	0,  // 0: dlu.v1.Day.morning:type_name -> dlu.v1.Subject
+	0,  // 1: dlu.v1.Day.afternoon:type_name -> dlu.v1.Subject
+	0,  // 2: dlu.v1.Day.evening:type_name -> dlu.v1.Subject
+	1,  // 3: dlu.v1.Schedule.days:type_name -> dlu.v1.Day
+	2,  // 4: dlu.v1.Schedule.meta:type_name -> dlu.v1.Meta
+	3,  // 5: dlu.v1.Schedule.warnings:type_name -> dlu.v1.Warning
+	5,  // 6: dlu.v1.GetScheduleRequest.week:type_name -> dlu.v1.WeekQuery
+	5,  // 7: dlu.v1.GetBatchRequest.week:type_name -> dlu.v1.WeekQuery
+	12, // 8: dlu.v1.GetBatchResponse.schedules:type_name -> dlu.v1.GetBatchResponse.SchedulesEntry
+	13, // 9: dlu.v1.GetBatchResponse.errors:type_name -> dlu.v1.GetBatchResponse.ErrorsEntry
+	5,  // 10: dlu.v1.StreamChangesRequest.week:type_name -> dlu.v1.WeekQuery
+	0,  // 11: dlu.v1.Change.before:type_name -> dlu.v1.Subject
+	0,  // 12: dlu.v1.Change.after:type_name -> dlu.v1.Subject
+	10, // 13: dlu.v1.ScheduleChange.changes:type_name -> dlu.v1.Change
+	4,  // 14: dlu.v1.ScheduleChange.schedule:type_name -> dlu.v1.Schedule
+	4,  // 15: dlu.v1.GetBatchResponse.SchedulesEntry.value:type_name -> dlu.v1.Schedule
+	6,  // 16: dlu.v1.ScheduleService.GetSchedule:input_type -> dlu.v1.GetScheduleRequest
+	7,  // 17: dlu.v1.ScheduleService.GetBatch:input_type -> dlu.v1.GetBatchRequest
+	9,  // 18: dlu.v1.ScheduleService.StreamChanges:input_type -> dlu.v1.StreamChangesRequest
+	4,  // 19: dlu.v1.ScheduleService.GetSchedule:output_type -> dlu.v1.Schedule
+	8,  // 20: dlu.v1.ScheduleService.GetBatch:output_type -> dlu.v1.GetBatchResponse
+	11, // 21: dlu.v1.ScheduleService.StreamChanges:output_type -> dlu.v1.ScheduleChange
+	19, // [19:22] is the sub-list for method output_type
+	16, // [16:19] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_schedule_proto_init() }
+func file_schedule_proto_init() {
+	if File_schedule_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_schedule_proto_rawDesc), len(file_schedule_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_schedule_proto_goTypes,
+		DependencyIndexes: file_schedule_proto_depIdxs,
+		MessageInfos:      file_schedule_proto_msgTypes,
+	}.Build()
+	File_schedule_proto = out.File
+	file_schedule_proto_goTypes = nil
+	file_schedule_proto_depIdxs = nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"hash/fnv"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+)
+
+const (
+	pngWidth   = 1600
+	pngMargin  = 32
+	pngDayCol  = 150
+	pngSize    = 18.0
+	pngLeading = 24
+	pngPad     = 10
+	pngHeadRow = 44
+	pngMinRow  = 72
+)
+
+var (
+	pngInk    = color.RGBA{0x21, 0x25, 0x29, 0xFF}
+	pngMuted  = color.RGBA{0x6C, 0x75, 0x7D, 0xFF}
+	pngGrid   = color.RGBA{0xCE, 0xD4, 0xDA, 0xFF}
+	pngHeader = color.RGBA{0xD9, 0xE1, 0xF2, 0xFF}
+	pngDayBg  = color.RGBA{0xF1, 0xF3, 0xF5, 0xFF}
+	// subjectPalette colors subjects, in images and embeds, so the same one is
+	// recognizable across the week.
+	subjectPalette = []color.RGBA{
+		{0xFF, 0xE8, 0xA3, 0xFF},
+		{0xC3, 0xE6, 0xCB, 0xFF},
+		{0xBE, 0xE3, 0xF8, 0xFF},
+		{0xF8, 0xCB, 0xD0, 0xFF},
+		{0xD7, 0xC9, 0xF2, 0xFF},
+		{0xFF, 0xD3, 0xB0, 0xFF},
+		{0xB8, 0xEB, 0xE4, 0xFF},
+		{0xE9, 0xD8, 0xC4, 0xFF},
+		{0xD4, 0xED, 0xA8, 0xFF},
+		{0xF5, 0xC6, 0xEC, 0xFF},
+	}
+)
+
+func subjectColor(sub dluparser.Subject) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(sub.Name))
+	return subjectPalette[h.Sum32()%uint32(len(subjectPalette))]
+}
+
+type pngBlock struct {
+	lines []string
+	fill  color.RGBA
+}
+
+func blockHeight(b pngBlock) int {
+	return len(b.lines)*pngLeading + pngPad
+}
+
+// renderPNG draws the week as a grid with a row per day and a column per
+// session, sized to fit everything on one image.
+func renderPNG(s dluparser.Schedule) ([]byte, error) {
+	f := renderFont
+	slotW := (pngWidth - 2*pngMargin - pngDayCol) / len(dluparser.Slots)
+	textW := float64(slotW - 4*pngPad)
+
+	title, subtitle := scheduleHeading(s)
+
+	days := daysByIndex(s)
+	type row struct {
+		day    []string
+		cells  [][]pngBlock
+		height int
+	}
+	var rows []row
+	for n := 1; n <= 7; n++ {
+		day := days[n]
+		r := row{day: []string{dluparser.VietnameseDayNames[n]}, cells: make([][]pngBlock, len(dluparser.Slots))}
+		if day.Date != "" {
+			r.day = append(r.day, shortDate(day.Date))
+		}
+		r.height = max(pngMinRow, len(r.day)*pngLeading+2*pngPad)
+		for i, subjects := range [][]dluparser.Subject{day.Sang, day.Chieu, day.Toi} {
+			h := pngPad
+			for _, sub := range subjects {
+				text := sessionText(sub)
+				if sub.Period != "" {
+					text += "\nTiết: " + sub.Period
+				}
+				b := pngBlock{fill: subjectColor(sub)}
+				for _, l := range strings.Split(text, "\n") {
+					b.lines = append(b.lines, wrapText(f, l, pngSize, textW)...)
+				}
+				r.cells[i] = append(r.cells[i], b)
+				h += blockHeight(b) + pngPad
+			}
+			r.height = max(r.height, h)
+		}
+		rows = append(rows, r)
+	}
+
+	top := pngMargin + 44
+	if subtitle != "" {
+		top += 28
+	}
+	height := top + pngHeadRow + pngMargin
+	for _, r := range rows {
+		height += r.height
+	}
+
+	c := newCanvas(pngWidth, height, f)
+	c.rect(0, 0, pngWidth, height, color.White)
+	c.text(pngMargin, pngMargin+30, 30, title, pngInk)
+	if subtitle != "" {
+		c.text(pngMargin, pngMargin+66, pngSize, subtitle, pngMuted)
+	}
+
+	gridW := pngDayCol + slotW*len(dluparser.Slots)
+	y := top
+	c.rect(pngMargin, y, gridW, pngHeadRow, pngHeader)
+	c.text(pngMargin+pngPad, float64(y+29), pngSize, "Thứ", pngInk)
+	for i, slot := range dluparser.Slots {
+		c.text(float64(pngMargin+pngDayCol+i*slotW+pngPad), float64(y+29), pngSize, slot, pngInk)
+	}
+	y += pngHeadRow
+
+	for _, r := range rows {
+		c.rect(pngMargin, y, pngDayCol, r.height, pngDayBg)
+		for j, l := range r.day {
+			c.text(pngMargin+pngPad, float64(y+pngPad+18+j*pngLeading), pngSize, l, pngInk)
+		}
+		for i, blocks := range r.cells {
+			x := pngMargin + pngDayCol + i*slotW
+			by := y + pngPad
+			for _, b := range blocks {
+				c.rect(x+pngPad, by, slotW-2*pngPad, blockHeight(b), b.fill)
+				for j, l := range b.lines {
+					if l != "" {
+						c.text(float64(x+2*pngPad), float64(by+pngPad/2+18+j*pngLeading), pngSize, l, pngInk)
+					}
+				}
+				by += blockHeight(b) + pngPad
+			}
+		}
+		c.rect(pngMargin, y, gridW, 1, pngGrid)
+		y += r.height
+	}
+
+	// Grid lines go on last so they frame the colored blocks.
+	c.rect(pngMargin, top, gridW, 1, pngGrid)
+	c.rect(pngMargin, y, gridW+1, 1, pngGrid)
+	c.rect(pngMargin, top, 1, y-top, pngGrid)
+	for i := 0; i <= len(dluparser.Slots); i++ {
+		c.rect(pngMargin+pngDayCol+i*slotW, top, 1, y-top, pngGrid)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.RGBA); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// optionsURL is the portal page carrying the year, term and week pickers.
+// Their markup isn't part of the timetable page, so features built on them
+// need it configured.
+var optionsURL = getenv("DLU_OPTIONS_URL")
+
+const optionsTTL = time.Hour
+
+var errOptionsNotConfigured = errors.New("Portal options source is not configured")
+
+type cachedSelects struct {
+	selects map[string][]dluparser.Option
+	at      time.Time
+}
+
+var (
+	selectsMu    sync.Mutex
+	selectsCache = make(map[string]cachedSelects)
+)
+
+// portalSelects fetches the picker page for a year and term, either of which
+// may be empty, and returns its dropdowns by name. Pages are reused for an
+// hour; the lists change once a term at most.
+func portalSelects(ctx context.Context, yearStudy, termID string) (map[string][]dluparser.Option, error) {
+	if optionsURL == "" {
+		return nil, errOptionsNotConfigured
+	}
+	v := url.Values{}
+	if yearStudy != "" {
+		v.Set("YearStudy", yearStudy)
+	}
+	if termID != "" {
+		v.Set("TermID", termID)
+	}
+	u := optionsURL
+	if len(v) > 0 {
+		u += "?" + v.Encode()
+	}
+
+	selectsMu.Lock()
+	if c, ok := selectsCache[u]; ok && time.Since(c.at) < optionsTTL {
+		selectsMu.Unlock()
+		return c.selects, nil
+	}
+	selectsMu.Unlock()
+
+	body, err := fetchURL(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	selects, err := dluparser.ParseSelects(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	selectsMu.Lock()
+	selectsCache[u] = cachedSelects{selects, time.Now()}
+	selectsMu.Unlock()
+	return selects, nil
+}
+
+// pickSelect returns the first dropdown present among names.
+func pickSelect(selects map[string][]dluparser.Option, names ...string) []dluparser.Option {
+	for _, name := range names {
+		if opts, ok := selects[strings.ToLower(name)]; ok && opts != nil {
+			return opts
+		}
+	}
+	return []dluparser.Option{}
+}
+
+// optionsHandler returns the values the portal offers for each schedule
+// parameter, keyed by parameter name. The week list depends on the year and
+// term, so those may be passed to get it for a term other than the
+// portal's default.
+func optionsHandler(c *gin.Context) {
+	if optionsURL == "" {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": errOptionsNotConfigured.Error()})
+		return
+	}
+	q, err := bindOptionalTerm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	selects, err := portalSelects(c.Request.Context(), q.YearStudy, q.TermID)
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"YearStudy": pickSelect(selects, "YearStudy"),
+		"TermID":    pickSelect(selects, "TermID"),
+		"Week":      pickSelect(selects, "Week", "WeekID"),
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// SlimSubject is the stable "slim" projection of Subject for dense list views.
+type SlimSubject struct {
+	Name   string `json:"ten_mon"`
+	Room   string `json:"phong"`
+	Period string `json:"tiet"`
+}
+
+type SlimDaySchedule struct {
+	Sang  []SlimSubject `json:"sang"`
+	Chieu []SlimSubject `json:"chieu"`
+	Toi   []SlimSubject `json:"toi"`
+}
+
+type SlimDay struct {
+	Weekday        string `json:"weekday,omitempty"`
+	VietnameseName string `json:"vietnamese_name"`
+	Date           string `json:"date,omitempty"`
+	SlimDaySchedule
+}
+
+type SlimSchedule struct {
+	Class     string               `json:"class"`
+	Week      string               `json:"week"`
+	Days      []SlimDay            `json:"days"`
+	Summary   string               `json:"summary,omitempty"`
+	Conflicts []dluparser.Conflict `json:"conflicts,omitempty"`
+	Meta      *dluparser.Meta      `json:"meta,omitempty"`
+	Warnings  []dluparser.Warning  `json:"warnings,omitempty"`
+}
+
+func slimSubjects(subjects []dluparser.Subject) []SlimSubject {
+	if subjects == nil {
+		return nil
+	}
+	slim := make([]SlimSubject, len(subjects))
+	for i, s := range subjects {
+		slim[i] = SlimSubject{Name: s.Name, Room: s.Room, Period: s.Period}
+	}
+	return slim
+}
+
+func slimSchedule(s dluparser.Schedule) SlimSchedule {
+	var days []SlimDay
+	for _, d := range s.OrderedDays() {
+		days = append(days, SlimDay{
+			Weekday:        d.Weekday,
+			VietnameseName: d.VietnameseName,
+			Date:           d.Date,
+			SlimDaySchedule: SlimDaySchedule{
+				Sang:  slimSubjects(d.Sang),
+				Chieu: slimSubjects(d.Chieu),
+				Toi:   slimSubjects(d.Toi),
+			},
+		})
+	}
+	return SlimSchedule{
+		Class:     s.Class,
+		Week:      s.Week,
+		Days:      days,
+		Summary:   s.Summary,
+		Conflicts: s.Conflicts,
+		Meta:      s.Meta,
+		Warnings:  s.Warnings,
+	}
+}
+
+// project applies a named projection to a schedule; "" and "full" return it
+// unchanged.
+func project(s dluparser.Schedule, projection string) (any, error) {
+	switch projection {
+	case "", "full":
+		return s, nil
+	case "slim":
+		return slimSchedule(s), nil
+	}
+	return nil, fmt.Errorf("unknown projection %q", projection)
+}
+
+// withoutRawLessons returns a copy of s with the "da_hoc" strings cleared;
+// renderers that reproduce the page still get the original.
+func withoutRawLessons(s dluparser.Schedule) dluparser.Schedule {
+	days := make(map[string]dluparser.DaySchedule, len(s.Days))
+	for name, d := range s.Days {
+		days[name] = dluparser.DaySchedule{
+			Sang:  clearLessons(d.Sang),
+			Chieu: clearLessons(d.Chieu),
+			Toi:   clearLessons(d.Toi),
+		}
+	}
+	s.Days = days
+	if s.Conflicts != nil {
+		conflicts := slices.Clone(s.Conflicts)
+		for i := range conflicts {
+			conflicts[i].Subjects = clearLessons(conflicts[i].Subjects)
+		}
+		s.Conflicts = conflicts
+	}
+	return s
+}
+
+func clearLessons(subjects []dluparser.Subject) []dluparser.Subject {
+	if subjects == nil {
+		return nil
+	}
+	out := slices.Clone(subjects)
+	for i := range out {
+		out[i].Lessons = ""
+	}
+	return out
+}
+
+// jsonFields lists the JSON keys of a struct type, for validating ?fields=.
+func jsonFields(v any) []string {
+	t := reflect.TypeOf(v)
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sessionKeys are the keys holding subject lists in every schedule shape.
+var sessionKeys = map[string]bool{"sang": true, "chieu": true, "toi": true, "morning": true, "afternoon": true, "evening": true, "subjects": true}
+
+// selectFields trims every subject in view down to fields. It works on the
+// encoded JSON so it applies equally to the full, slim and English shapes.
+func selectFields(view any, fields []string) (any, error) {
+	b, err := json.Marshal(view)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var generic any
+	if err := d.Decode(&generic); err != nil {
+		return nil, err
+	}
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keep[f] = true
+	}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if subjects, ok := child.([]any); ok && sessionKeys[k] {
+					for _, sub := range subjects {
+						if m, ok := sub.(map[string]any); ok {
+							maps.DeleteFunc(m, func(key string, _ any) bool { return !keep[key] })
+						}
+					}
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(generic)
+	return generic, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// A provider is a QLGD (EduSoft) portal timetables are read from. DLU is the
+// default, served under /dlu; other universities running the same software
+// are configured with DLU_PROVIDERS and served under /u/:provider.
+type provider interface {
+	Name() string
+	FetchSchedule(ctx context.Context, q scheduleQuery) (dluparser.Schedule, error)
+}
+
+const defaultProvider = "dlu"
+
+var providerNameRe = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// providers is the registry, keyed by name. checkConfig fills it.
+var providers = map[string]provider{}
+
+// qlgdProvider fetches from one portal, trying its templates in order. Each
+// has its own circuit breaker so one campus going down doesn't cut off the
+// others.
+type qlgdProvider struct {
+	name      string
+	templates []scheduleTemplate
+	breaker   *breaker
+}
+
+func (p *qlgdProvider) Name() string { return p.name }
+
+func (p *qlgdProvider) FetchSchedule(ctx context.Context, q scheduleQuery) (dluparser.Schedule, error) {
+	var schedule dluparser.Schedule
+	attempts := 0
+	for i, t := range p.templates {
+		// Only the primary template's outcome counts on the breaker; a
+		// fallback that fails isn't the provider being down.
+		b := p.breaker
+		if i > 0 {
+			b = nil
+		}
+		body, n, err := fetchThrough(ctx, b, t.URL+"?"+scheduleParams(q))
+		attempts += n
+		var s dluparser.Schedule
+		if err == nil {
+			s, err = parsePage(ctx, t.parser, body, q)
+		}
+		if err != nil {
+			// A fallback that fails leaves the first template's empty week.
+			if i == 0 {
+				return dluparser.Schedule{}, err
+			}
+			break
+		}
+		if i == 0 || len(s.Days) > 0 {
+			schedule = s
+			schedule.Meta.Template = t.Name
+		}
+		if len(s.Days) > 0 {
+			break
+		}
+	}
+	schedule.Meta.Attempts = attempts
+	return schedule, nil
+}
+
+// loadProviders builds the registry: DLU from DLU_UPSTREAM_URL and
+// DLU_TEMPLATES, then each "name=URL" entry of the comma-separated
+// DLU_PROVIDERS. A provider's quirks are set with DLU_PROVIDER_<NAME>_TEMPLATES
+// and DLU_PROVIDER_<NAME>_SELECTOR_{HEADER,ROWS,DAY,SLOT}, defaulting to the
+// standard layouts and selectors.
+func loadProviders() (map[string]provider, error) {
+	templates, err := loadTemplates(upstreamURL, templateNames, htmlParser)
+	if err != nil {
+		return nil, fmt.Errorf("DLU_TEMPLATES: %w", err)
+	}
+	out := map[string]provider{defaultProvider: &qlgdProvider{name: defaultProvider, templates: templates, breaker: upstreamBreaker}}
+
+	for _, entry := range strings.Split(getenv("DLU_PROVIDERS"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, base, _ := strings.Cut(entry, "=")
+		name, base = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(base)
+		if !providerNameRe.MatchString(name) {
+			return nil, fmt.Errorf("DLU_PROVIDERS: invalid provider name %q, expected lower-case letters and digits", name)
+		}
+		if _, dup := out[name]; dup {
+			return nil, fmt.Errorf("DLU_PROVIDERS: provider %q configured twice", name)
+		}
+		if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("DLU_PROVIDERS: invalid URL %q for provider %s", base, name)
+		}
+
+		prefix := "DLU_PROVIDER_" + strings.ToUpper(name) + "_"
+		parser, err := dluparser.NewHTMLParser(loadSelectors(prefix + "SELECTOR_"))
+		if err != nil {
+			return nil, fmt.Errorf("%sSELECTOR_*: %w", prefix, err)
+		}
+		names := strings.Split(envString(prefix+"TEMPLATES", "Mau2,Mau1,Mau3"), ",")
+		templates, err := loadTemplates(base, names, parser)
+		if err != nil {
+			return nil, fmt.Errorf("%sTEMPLATES: %w", prefix, err)
+		}
+		out[name] = &qlgdProvider{name: name, templates: templates, breaker: &breaker{}}
+	}
+	return out, nil
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// providerScheduleHandler serves /dlu's schedule from another portal.
+func providerScheduleHandler(c *gin.Context) {
+	if _, ok := providers[c.Param("provider")]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+		return
+	}
+	serveSchedule(c, "")
+}
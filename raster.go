@@ -0,0 +1,217 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// raster fills paths into an anti-aliased coverage mask by accumulating the
+// signed area each edge covers per pixel, then summing along each row. The
+// fill rule is non-zero.
+type raster struct {
+	w, h int
+	acc  []float64
+}
+
+func newRaster(w, h int) *raster {
+	return &raster{w: w, h: h, acc: make([]float64, (w+2)*h)}
+}
+
+func (r *raster) line(x0, y0, x1, y1 float64) {
+	if y0 == y1 {
+		return
+	}
+	dir := 1.0
+	if y0 > y1 {
+		dir = -1
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	clampX := func(x float64) float64 { return math.Max(0, math.Min(x, float64(r.w))) }
+	dxdy := (x1 - x0) / (y1 - y0)
+	x := x0
+	if y0 < 0 {
+		x -= y0 * dxdy
+		y0 = 0
+	}
+	y1 = math.Min(y1, float64(r.h))
+	stride := r.w + 2
+	for y := int(y0); float64(y) < y1; y++ {
+		row := y * stride
+		dy := math.Min(float64(y+1), y1) - math.Max(float64(y), y0)
+		next := x + dxdy*dy
+		d := dy * dir
+		a, b := clampX(x), clampX(next)
+		if a > b {
+			a, b = b, a
+		}
+		ai, bi := int(math.Floor(a)), int(math.Ceil(b))
+		if bi <= ai+1 {
+			mid := 0.5*(a+b) - float64(ai)
+			r.acc[row+ai] += d - d*mid
+			r.acc[row+ai+1] += d * mid
+		} else {
+			s := 1 / (b - a)
+			af := a - float64(ai)
+			a0 := 0.5 * s * (1 - af) * (1 - af)
+			bf := b - float64(bi) + 1
+			am := 0.5 * s * bf * bf
+			r.acc[row+ai] += d * a0
+			if bi == ai+2 {
+				r.acc[row+ai+1] += d * (1 - a0 - am)
+			} else {
+				a1 := s * (1.5 - af)
+				r.acc[row+ai+1] += d * (a1 - a0)
+				for xi := ai + 2; xi < bi-1; xi++ {
+					r.acc[row+xi] += d * s
+				}
+				a2 := a1 + float64(bi-ai-3)*s
+				r.acc[row+bi-1] += d * (1 - a2 - am)
+			}
+			r.acc[row+bi] += d * am
+		}
+		x = next
+	}
+}
+
+func (r *raster) quad(x0, y0, cx, cy, x1, y1 float64) {
+	const steps = 8
+	px, py := x0, y0
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / steps
+		u := 1 - t
+		x := u*u*x0 + 2*u*t*cx + t*t*x1
+		y := u*u*y0 + 2*u*t*cy + t*t*y1
+		r.line(px, py, x, y)
+		px, py = x, y
+	}
+}
+
+func (r *raster) mask() *image.Alpha {
+	m := image.NewAlpha(image.Rect(0, 0, r.w, r.h))
+	stride := r.w + 2
+	for y := 0; y < r.h; y++ {
+		sum := 0.0
+		for x := 0; x < r.w; x++ {
+			sum += r.acc[y*stride+x]
+			m.Pix[y*m.Stride+x] = uint8(math.Min(1, math.Abs(sum))*255 + 0.5)
+		}
+	}
+	return m
+}
+
+// glyphMask rasterizes one glyph at size pixels per em. The mask's origin
+// sits on the baseline at the glyph's origin, offset by the returned point.
+func (f *ttfFont) glyphMask(gid uint16, size float64) (*image.Alpha, image.Point) {
+	contours := f.outline(gid)
+	if len(contours) == 0 {
+		return nil, image.Point{}
+	}
+	scale := size / float64(f.unitsPerEm)
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, c := range contours {
+		for _, p := range c {
+			minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+			minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+		}
+	}
+	left, top := int(math.Floor(minX*scale)), int(math.Floor(-maxY*scale))
+	w, h := int(math.Ceil(maxX*scale))-left+1, int(math.Ceil(-minY*scale))-top+1
+	if w <= 0 || h <= 0 || w > 4096 || h > 4096 {
+		return nil, image.Point{}
+	}
+
+	r := newRaster(w, h)
+	pt := func(p ttfPoint) (float64, float64) {
+		return p.x*scale - float64(left), -p.y*scale - float64(top)
+	}
+	for _, c := range contours {
+		// Start on an on-curve point, inventing one between two off-curve
+		// points when the contour has none to start from.
+		start := 0
+		for start < len(c) && !c[start].on {
+			start++
+		}
+		var sx, sy float64
+		if start == len(c) {
+			sx, sy = pt(ttfPoint{(c[0].x + c[len(c)-1].x) / 2, (c[0].y + c[len(c)-1].y) / 2, true})
+			start = 0
+		} else {
+			sx, sy = pt(c[start])
+			start++
+		}
+
+		x, y := sx, sy
+		var cx, cy float64
+		ctrl := false
+		for i := 0; i < len(c); i++ {
+			p := c[(start+i)%len(c)]
+			px, py := pt(p)
+			switch {
+			case p.on && ctrl:
+				r.quad(x, y, cx, cy, px, py)
+				x, y, ctrl = px, py, false
+			case p.on:
+				r.line(x, y, px, py)
+				x, y = px, py
+			case ctrl:
+				mx, my := (cx+px)/2, (cy+py)/2
+				r.quad(x, y, cx, cy, mx, my)
+				x, y = mx, my
+				cx, cy = px, py
+			default:
+				cx, cy, ctrl = px, py, true
+			}
+		}
+		if ctrl {
+			r.quad(x, y, cx, cy, sx, sy)
+		} else {
+			r.line(x, y, sx, sy)
+		}
+	}
+	return r.mask(), image.Pt(left, top)
+}
+
+// canvas draws text in one font, caching glyph masks per size.
+type canvas struct {
+	*image.RGBA
+	font  *ttfFont
+	cache map[[2]float64]*canvasGlyph
+}
+
+type canvasGlyph struct {
+	mask   *image.Alpha
+	offset image.Point
+}
+
+func newCanvas(w, h int, font *ttfFont) *canvas {
+	return &canvas{RGBA: image.NewRGBA(image.Rect(0, 0, w, h)), font: font, cache: map[[2]float64]*canvasGlyph{}}
+}
+
+func (c *canvas) rect(x, y, w, h int, col color.Color) {
+	draw.Draw(c.RGBA, image.Rect(x, y, x+w, y+h), image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// text draws s with its baseline at y.
+func (c *canvas) text(x, y, size float64, s string, col color.Color) {
+	src := image.NewUniform(col)
+	scale := size / float64(c.font.unitsPerEm)
+	for _, ch := range s {
+		gid := c.font.glyph(ch)
+		key := [2]float64{float64(gid), size}
+		g, ok := c.cache[key]
+		if !ok {
+			m, off := c.font.glyphMask(gid, size)
+			g = &canvasGlyph{m, off}
+			c.cache[key] = g
+		}
+		if g.mask != nil {
+			at := image.Pt(int(math.Round(x)), int(math.Round(y))).Add(g.offset)
+			draw.DrawMask(c.RGBA, g.mask.Bounds().Add(at), src, image.Point{}, g.mask, image.Point{}, draw.Over)
+		}
+		if int(gid) < len(c.font.advances) {
+			x += float64(c.font.advances[gid]) * scale
+		}
+	}
+}
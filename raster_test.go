@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"testing"
+)
+
+func TestRasterCoverage(t *testing.T) {
+	type rect struct{ x0, y0, x1, y1 float64 }
+	fill := func(r *raster, q rect, clockwise bool) {
+		pts := [][2]float64{{q.x0, q.y0}, {q.x1, q.y0}, {q.x1, q.y1}, {q.x0, q.y1}}
+		if !clockwise {
+			pts[1], pts[3] = pts[3], pts[1]
+		}
+		for i, p := range pts {
+			n := pts[(i+1)%len(pts)]
+			r.line(p[0], p[1], n[0], n[1])
+		}
+	}
+	tests := []struct {
+		name  string
+		rects []rect
+		// ccw fills the second rect the other way round.
+		ccw  bool
+		want [][]uint8
+	}{
+		{"whole pixels", []rect{{1, 0, 3, 2}}, false, [][]uint8{
+			{0, 255, 255, 0},
+			{0, 255, 255, 0},
+		}},
+		{"half pixels", []rect{{0.5, 0, 2.5, 1}}, false, [][]uint8{
+			{128, 255, 128, 0},
+			{0, 0, 0, 0},
+		}},
+		{"quarter pixel", []rect{{0, 0, 0.5, 0.5}}, false, [][]uint8{
+			{64, 0, 0, 0},
+			{0, 0, 0, 0},
+		}},
+		// Non-zero winding: overlaps stay filled, a reversed contour cuts a
+		// hole.
+		{"overlap", []rect{{0, 0, 2, 2}, {1, 0, 3, 2}}, false, [][]uint8{
+			{255, 255, 255, 0},
+			{255, 255, 255, 0},
+		}},
+		{"hole", []rect{{0, 0, 4, 2}, {1, 0, 3, 2}}, true, [][]uint8{
+			{255, 0, 0, 255},
+			{255, 0, 0, 255},
+		}},
+		{"clipped", []rect{{-2, -1, 2, 1}, {3, 1, 9, 9}}, false, [][]uint8{
+			{255, 255, 0, 0},
+			{0, 0, 0, 255},
+		}},
+	}
+	for _, tt := range tests {
+		r := newRaster(4, 2)
+		for i, q := range tt.rects {
+			fill(r, q, !(tt.ccw && i == 1))
+		}
+		m := r.mask()
+		for y, row := range tt.want {
+			got := m.Pix[y*m.Stride : y*m.Stride+len(row)]
+			if !bytes.Equal(got, row) {
+				t.Errorf("%s: row %d = %v, want %v", tt.name, y, got, row)
+			}
+		}
+	}
+}
+
+func TestRasterQuad(t *testing.T) {
+	// A hump over a flat base: the curve rises from the bottom corners to
+	// y=2 in the middle, so the rows above it stay empty, the pixels it
+	// crosses are partly covered and the bottom row is full.
+	r := newRaster(4, 4)
+	r.quad(0, 4, 2, 0, 4, 4)
+	r.line(4, 4, 0, 4)
+	m := r.mask()
+	for x := range 4 {
+		if a := m.AlphaAt(x, 1).A; a != 0 {
+			t.Errorf("pixel (%d,1) above the curve has coverage %d", x, a)
+		}
+	}
+	if m.AlphaAt(1, 3).A < 200 || m.AlphaAt(2, 3).A < 200 {
+		t.Errorf("bottom middle not filled: %v", m.Pix[3*m.Stride:3*m.Stride+4])
+	}
+	if a := m.AlphaAt(1, 2).A; a == 0 || a == 255 {
+		t.Errorf("curve edge pixel coverage %d, want partial", a)
+	}
+}
+
+func TestGlyphMask(t *testing.T) {
+	for _, r := range "AoĐệg" {
+		m, off := renderFont.glyphMask(renderFont.glyph(r), 24)
+		if m == nil {
+			t.Fatalf("%c: no mask", r)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, m); err != nil {
+			t.Fatal(err)
+		}
+		golden(t, fmt.Sprintf("glyph-%04X.png", r), buf.Bytes())
+		// The origin is on the baseline, so only g's descender and ệ's dot
+		// reach below it.
+		if below := off.Y + m.Bounds().Dy(); (below > 2) != (r == 'g' || r == 'ệ') {
+			t.Errorf("%c: mask reaches %dpx below the baseline", r, below)
+		}
+	}
+	if m, _ := renderFont.glyphMask(renderFont.glyph(' '), 24); m != nil {
+		t.Errorf("space has a mask")
+	}
+}
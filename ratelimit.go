@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenBucket allows rate events per second on average with bursts of up to
+// burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// take spends a token if one is available, otherwise reporting how long
+// until one will be.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+var (
+	rateLimit  = envFloat("DLU_RATE_LIMIT", 10)
+	rateBurst  = envInt("DLU_RATE_BURST", 30)
+	rateExempt = loadRateExempt(getenv("DLU_RATE_LIMIT_EXEMPT"))
+)
+
+// trustedProxies are the reverse proxies whose X-Forwarded-For is believed
+// when working out a client's IP. None are trusted by default, so clients
+// can't dodge the limiter by sending the header themselves.
+var trustedProxies = splitList(getenv("DLU_TRUSTED_PROXIES"))
+
+func splitList(raw string) []string {
+	var out []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// rateExemptions lists clients the limiter skips: IPs or CIDRs, and
+// User-Agent substrings for known crawlers.
+type rateExemptions struct {
+	nets   []*net.IPNet
+	agents []string
+}
+
+func loadRateExempt(raw string) rateExemptions {
+	var ex rateExemptions
+	for _, entry := range splitList(raw) {
+		switch {
+		case strings.Contains(entry, "/"):
+			_, n, err := net.ParseCIDR(entry)
+			if err != nil {
+				configError("DLU_RATE_LIMIT_EXEMPT entry %q: invalid CIDR", entry)
+				continue
+			}
+			ex.nets = append(ex.nets, n)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 128
+			}
+			ex.nets = append(ex.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			ex.agents = append(ex.agents, strings.ToLower(entry))
+		}
+	}
+	return ex
+}
+
+func (ex rateExemptions) match(ip, userAgent string) bool {
+	if addr := net.ParseIP(ip); addr != nil {
+		for _, n := range ex.nets {
+			if n.Contains(addr) {
+				return true
+			}
+		}
+	}
+	ua := strings.ToLower(userAgent)
+	for _, a := range ex.agents {
+		if strings.Contains(ua, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipLimiter keeps one bucket per client IP, forgetting clients idle long
+// enough for their bucket to have refilled.
+type ipLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+func (l *ipLimiter) take(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	full := time.Duration(float64(rateBurst) / rateLimit * float64(time.Second))
+	if now.Sub(l.swept) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > full {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = newTokenBucket(rateLimit, rateBurst)
+		l.buckets[ip] = b
+	}
+	return b.take(now)
+}
+
+// rateLimiter answers 429 with Retry-After once a client IP exceeds
+// DLU_RATE_LIMIT requests per second (bursts up to DLU_RATE_BURST). Health
+// checks are never limited, and requests with an API key were already
+// limited per key.
+func rateLimiter() gin.HandlerFunc {
+	l := &ipLimiter{buckets: make(map[string]*tokenBucket)}
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path == "/healthz" || path == "/readyz" || c.GetString("apiKey") != "" || rateExempt.match(c.ClientIP(), c.Request.UserAgent()) {
+			c.Next()
+			return
+		}
+		if ok, wait := l.take(c.ClientIP()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+var (
+	upstreamConcurrency = envInt("DLU_UPSTREAM_CONCURRENCY", 8)
+	upstreamRate        = envFloat("DLU_UPSTREAM_RATE", 5)
+)
+
+// politeLimiter caps outgoing requests to the portal, however many clients
+// are waiting: at most DLU_UPSTREAM_CONCURRENCY in flight and
+// DLU_UPSTREAM_RATE started per second. Excess fetches queue until their
+// turn or until their context ends.
+type politeLimiter struct {
+	slots  chan struct{}
+	mu     sync.Mutex
+	bucket *tokenBucket
+}
+
+var upstreamLimiter = &politeLimiter{
+	slots:  make(chan struct{}, upstreamConcurrency),
+	bucket: newTokenBucket(upstreamRate, 1),
+}
+
+// acquire waits for a slot and a token; the returned func frees the slot.
+func (l *politeLimiter) acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-l.slots }
+
+	l.mu.Lock()
+	ok, wait := l.bucket.take(time.Now())
+	if !ok {
+		// Reserve the token we're about to wait for, so queued fetches are
+		// spaced out instead of all waking at once.
+		l.bucket.tokens--
+	}
+	l.mu.Unlock()
+	if ok {
+		return release, nil
+	}
+
+	select {
+	case <-time.After(wait):
+		return release, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.bucket.tokens++
+		l.mu.Unlock()
+		release()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// courseRemaining is one course's lesson count as of the latest week that
+// lists it. Sessions of the same course in different groups or weeks are
+// folded together by code, or by name for subjects without one.
+type courseRemaining struct {
+	Code             string   `json:"ma_mon,omitempty"`
+	Name             string   `json:"ten_mon"`
+	Groups           []string `json:"nhom"`
+	LessonsDone      int      `json:"lessons_done"`
+	LessonsTotal     int      `json:"lessons_total"`
+	LessonsRemaining int      `json:"lessons_remaining"`
+	ProgressPercent  float64  `json:"progress_percent"`
+	LastWeek         string   `json:"last_week"`
+	// Status is "near_completion" at or below ?near= lessons left (default
+	// 3), "many_left" at or above ?many= (default 15), else "in_progress".
+	Status string `json:"status"`
+}
+
+type remainingResponse struct {
+	Class    string              `json:"class"`
+	Meta     dluparser.Meta      `json:"meta"`
+	Courses  []courseRemaining   `json:"courses"`
+	Failed   []int               `json:"failed_weeks,omitempty"`
+	Warnings []dluparser.Warning `json:"warnings,omitempty"`
+}
+
+// remainingWeeks is the explicit range when one is given, and otherwise the
+// term's weeks from the portal's week picker. The bool is false once an
+// error response has been written.
+func remainingWeeks(c *gin.Context, q scheduleQuery) ([]int, bool) {
+	var calendar []calendarWeek
+	if c.Query("weeks") == "" && c.Query("fromWeek") == "" && c.Query("toWeek") == "" {
+		var err error
+		calendar, err = portalCalendar(c.Request.Context(), q)
+		if err != nil && !errors.Is(err, errOptionsNotConfigured) {
+			upstreamError(c, err)
+			return nil, false
+		}
+	}
+	if len(calendar) == 0 {
+		weeks, err := bindWeekRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		return weeks, true
+	}
+	if len(calendar) > maxRangeWeeks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("the term has %d weeks, at most %d per request; pass fromWeek and toWeek", len(calendar), maxRangeWeeks)})
+		return nil, false
+	}
+	weeks := make([]int, len(calendar))
+	for i, w := range calendar {
+		weeks[i] = w.Week
+	}
+	return weeks, true
+}
+
+// aggregateRemaining folds the weeks' subjects into courses, keeping each
+// course's highest lesson count. Subjects whose "Đã học" doesn't parse are
+// left out and reported as warnings.
+func aggregateRemaining(weeks []int, schedules []*dluparser.Schedule, near, many int) ([]courseRemaining, []dluparser.Warning) {
+	courses := make(map[string]*courseRemaining)
+	var warnings []dluparser.Warning
+	warned := make(map[string]bool)
+	for i, s := range schedules {
+		if s == nil {
+			continue
+		}
+		week := strconv.Itoa(weeks[i])
+		for _, d := range s.OrderedDays() {
+			for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+				for _, sub := range slot {
+					if !sub.HasProgress() {
+						if key := sub.Name + "\x00" + sub.Lessons; !warned[key] {
+							warned[key] = true
+							warnings = append(warnings, dluparser.Warning{
+								Code:    "lessonsUnparsed",
+								Message: fmt.Sprintf("week %s: %s has lesson count %q, left out", week, sub.Name, sub.Lessons),
+							})
+						}
+						continue
+					}
+					key := sub.Code
+					if key == "" {
+						key = strings.ToLower(dluparser.FoldDiacritics(sub.Name))
+					}
+					cr, ok := courses[key]
+					if !ok {
+						cr = &courseRemaining{Code: sub.Code, Name: sub.Name, Groups: []string{}}
+						courses[key] = cr
+					}
+					if sub.Group != "" && !slices.Contains(cr.Groups, sub.Group) {
+						cr.Groups = append(cr.Groups, sub.Group)
+					}
+					if !ok || sub.LessonsDone >= cr.LessonsDone {
+						cr.LessonsDone, cr.LessonsTotal = sub.LessonsDone, sub.LessonsTotal
+						cr.LessonsRemaining, cr.ProgressPercent = sub.LessonsRemaining, sub.ProgressPercent
+					}
+					cr.LastWeek = week
+				}
+			}
+		}
+	}
+
+	out := make([]courseRemaining, 0, len(courses))
+	for _, cr := range courses {
+		sort.Strings(cr.Groups)
+		switch {
+		case cr.LessonsRemaining <= near:
+			cr.Status = "near_completion"
+		case cr.LessonsRemaining >= many:
+			cr.Status = "many_left"
+		default:
+			cr.Status = "in_progress"
+		}
+		out = append(out, *cr)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].LessonsRemaining != out[j].LessonsRemaining {
+			return out[i].LessonsRemaining < out[j].LessonsRemaining
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, warnings
+}
+
+// remainingHandler summarizes how many lessons each of a class's courses has
+// left, over a range of weeks or the whole term. Weeks that fail are listed
+// in failed_weeks; only when every week fails is the upstream error returned.
+func remainingHandler(c *gin.Context) {
+	q, err := bindTermQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	near, many := 3, 15
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"near", &near}, {"many", &many}} {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s %q, expected a lesson count", p.name, v)})
+				return
+			}
+			*p.dst = n
+		}
+	}
+	weeks, ok := remainingWeeks(c, q)
+	if !ok {
+		return
+	}
+
+	resp := remainingResponse{
+		Class: q.ClassStudentID,
+		Meta:  dluparser.Meta{YearStudy: q.YearStudy, TermID: q.TermID, Semester: q.Semester},
+	}
+	schedules := make([]*dluparser.Schedule, len(weeks))
+	var firstErr error
+	for i, res := range fetchWeeks(c.Request.Context(), q, weeks) {
+		if res.Err != nil {
+			resp.Failed = append(resp.Failed, weeks[i])
+			if firstErr == nil {
+				firstErr = res.Err
+			}
+			continue
+		}
+		schedules[i] = &res.Schedule
+	}
+	if len(resp.Failed) == len(weeks) {
+		upstreamError(c, firstErr)
+		return
+	}
+	resp.Courses, resp.Warnings = aggregateRemaining(weeks, schedules, near, many)
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRemaining(t *testing.T) {
+	// Week n has INF123 at 3n of 45 in group 1, and again in group 2 on
+	// Wednesday; INF201 is at 28 of 30 from week 2, and a seminar with no
+	// lesson count turns up in week 3.
+	h := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		week, _ := strconv.Atoi(r.URL.Query().Get("Week"))
+		cell := func(name, group string, done, total int) string {
+			return fmt.Sprintf("%s<br>Nhóm: %s<br>Lớp: CTK45<br>Tiết: 1-3<br>Phòng: A1.101<br>GV: Nguyễn Văn A<br>Đã học: %d/%d", name, group, done, total)
+		}
+		mon := cell("Lập trình Go (INF123)", "1", 3*week, 45)
+		wed := cell("Lập trình Go (INF123)", "2", 3*week-1, 45)
+		if week >= 2 {
+			wed += "<hr>" + cell("Cơ sở dữ liệu (INF201)", "2", 28, 30)
+		}
+		if week == 3 {
+			mon += "<hr>Seminar<br>Nhóm: 1<br>Lớp: CTK45<br>Tiết: 4-5<br>Đã học: chưa cập nhật"
+		}
+		fmt.Fprintf(w, `<html><body><div><div style="x">Tuần %d lớp: CTK45</div></div><table>
+<tr><th></th><th>Sáng</th><th>Chiều</th><th>Tối</th></tr>
+<tr><th>Thứ 2</th><td>%s</td><td></td><td></td></tr>
+<tr><th>Thứ 4</th><td>%s</td><td></td><td></td></tr></table></body></html>`, week, mon, wed)
+	})
+
+	rec := get(t, h, "/dlu/remaining?YearStudy=2024-2025&TermID=HK01&ClassStudentID=CTK45&fromWeek=1&toWeek=3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp remainingResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []courseRemaining{
+		{Code: "INF201", Name: "Cơ sở dữ liệu", Groups: []string{"2"}, LessonsDone: 28, LessonsTotal: 30, LessonsRemaining: 2, ProgressPercent: 93.3, LastWeek: "3", Status: "near_completion"},
+		{Code: "INF123", Name: "Lập trình Go", Groups: []string{"1", "2"}, LessonsDone: 9, LessonsTotal: 45, LessonsRemaining: 36, ProgressPercent: 20, LastWeek: "3", Status: "many_left"},
+	}
+	if len(resp.Courses) != len(want) {
+		t.Fatalf("courses = %+v, want %d", resp.Courses, len(want))
+	}
+	for i, w := range want {
+		got := resp.Courses[i]
+		if fmt.Sprint(got) != fmt.Sprint(w) {
+			t.Errorf("courses[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0].Code != "lessonsUnparsed" || !strings.Contains(resp.Warnings[0].Message, "Seminar") {
+		t.Errorf("warnings = %+v, want one lessonsUnparsed for Seminar", resp.Warnings)
+	}
+
+	// Thresholds move courses between statuses.
+	rec = get(t, h, "/dlu/remaining?YearStudy=2024-2025&TermID=HK01&ClassStudentID=CTK45&fromWeek=1&toWeek=3&near=1&many=40")
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	for _, c := range resp.Courses {
+		if c.Status != "in_progress" {
+			t.Errorf("near=1&many=40: %s is %s, want in_progress", c.Code, c.Status)
+		}
+	}
+
+	for _, target := range []string{
+		"/dlu/remaining?YearStudy=2024-2025&TermID=HK01&ClassStudentID=CTK45",
+		"/dlu/remaining?YearStudy=2024-2025&TermID=HK01&ClassStudentID=CTK45&fromWeek=1&toWeek=3&near=x",
+	} {
+		if rec := get(t, h, target); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, rec.Code)
+		}
+	}
+}
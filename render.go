@@ -0,0 +1,381 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+type renderer struct {
+	ContentType string
+	// Extension, when set, makes the response a download named after the
+	// class and week.
+	Extension string
+	// Render receives the filtered schedule and its projected view.
+	Render func(s dluparser.Schedule, view any) ([]byte, error)
+}
+
+// renderers is the registry of ?format= values /dlu understands.
+var renderers = map[string]renderer{
+	"json": {
+		ContentType: "application/json; charset=utf-8",
+		Render: func(_ dluparser.Schedule, view any) ([]byte, error) {
+			return json.Marshal(view)
+		},
+	},
+	"ics": {
+		ContentType: "text/calendar; charset=utf-8",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderICS(s)
+		},
+	},
+	"csv": {
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   "csv",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderCSV(s)
+		},
+	},
+	"xlsx": {
+		ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		Extension:   "xlsx",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderXLSX(s)
+		},
+	},
+	"pdf": {
+		ContentType: "application/pdf",
+		Extension:   "pdf",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderPDF(s)
+		},
+	},
+	"png": {
+		ContentType: "image/png",
+		Extension:   "png",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderPNG(s)
+		},
+	},
+	"html": {
+		ContentType: "text/html; charset=utf-8",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderEmbed(s)
+		},
+	},
+	"markdown": {
+		ContentType: "text/markdown; charset=utf-8",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderMarkdown(s)
+		},
+	},
+	"text": {
+		ContentType: "text/plain; charset=utf-8",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return renderText(s)
+		},
+	},
+	"original": {
+		ContentType: "text/plain; charset=utf-8",
+		Render: func(s dluparser.Schedule, _ any) ([]byte, error) {
+			return []byte(s.String()), nil
+		},
+	},
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// acceptedTypes maps the media types clients may ask for in Accept, when
+// they don't pass ?format=, to formats.
+var acceptedTypes = map[string]string{
+	"application/json": "json",
+	"text/csv":         "csv",
+	"text/calendar":    "ics",
+	"text/html":        "html",
+}
+
+// defaultFormat is the format used when neither ?format= nor Accept picks
+// one.
+var defaultFormat = envString("DLU_DEFAULT_FORMAT", "json")
+
+// negotiateFormat picks the format the Accept header prefers most, falling
+// back to DLU_DEFAULT_FORMAT when it names none of acceptedTypes (including
+// */*).
+func negotiateFormat(accept string) string {
+	best, bestQ := defaultFormat, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		format, ok := acceptedTypes[strings.ToLower(strings.TrimSpace(params[0]))]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, p := range params[1:] {
+			if v, found := strings.CutPrefix(strings.TrimSpace(p), "q="); found {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// viewOptions are the presentation parameters shared by schedule endpoints.
+// They are bound before fetching so bad input never costs an upstream request.
+type viewOptions struct {
+	Day        string
+	Session    string
+	Teacher    string
+	Subject    string
+	Include    map[string]bool
+	Projection string
+	View       string
+	Format     string
+	Lang       string
+	Fields     []string
+	// Negotiated is set when Format came from the Accept header.
+	Negotiated bool
+	// Raw keeps the unparsed "da_hoc" string alongside the lesson counts.
+	Raw bool
+}
+
+func bindViewOptions(c *gin.Context) (viewOptions, error) {
+	opts := viewOptions{
+		Day:        c.Query("day"),
+		Session:    c.Query("session"),
+		Teacher:    c.Query("teacher"),
+		Subject:    c.Query("subject"),
+		Projection: c.Query("projection"),
+		View:       c.Query("view"),
+		Format:     c.Query("format"),
+		Lang:       c.Query("lang"),
+		Raw:        c.Query("raw") == "1",
+	}
+	if opts.Format == "" {
+		opts.Format, opts.Negotiated = negotiateFormat(c.GetHeader("Accept")), true
+	}
+	if opts.Day != "" {
+		if _, ok := dluparser.WeekdayIndex(opts.Day); !ok {
+			return opts, fmt.Errorf("unrecognized day %q", opts.Day)
+		}
+	}
+	if opts.Session != "" {
+		if _, ok := sessionIndex(opts.Session); !ok {
+			return opts, fmt.Errorf("unrecognized session %q, expected sang, chieu or toi", opts.Session)
+		}
+	}
+	if opts.Projection != "" && !slices.Contains(projections, opts.Projection) {
+		return opts, fmt.Errorf("unknown projection %q", opts.Projection)
+	}
+	if opts.View != "" && opts.View != "matrix" {
+		return opts, fmt.Errorf("unknown view %q", opts.View)
+	}
+	if opts.Lang != "" && !slices.Contains(langs, opts.Lang) {
+		return opts, fmt.Errorf("unknown lang %q", opts.Lang)
+	}
+	if opts.Lang == "en" && (opts.View != "" || (opts.Projection != "" && opts.Projection != "full")) {
+		return opts, errors.New("lang=en only applies to the full schedule")
+	}
+	if raw := c.Query("fields"); raw != "" {
+		known := jsonFields(dluparser.Subject{})
+		if opts.Lang == "en" {
+			known = jsonFields(EnglishSubject{})
+		}
+		for _, f := range strings.Split(raw, ",") {
+			if f = strings.TrimSpace(f); f == "" {
+				continue
+			}
+			if !slices.Contains(known, f) {
+				return opts, fmt.Errorf("unknown field %q, expected some of %s", f, strings.Join(known, ", "))
+			}
+			opts.Fields = append(opts.Fields, f)
+		}
+		if opts.View != "" {
+			return opts, errors.New("fields doesn't apply to view=matrix")
+		}
+	}
+	if _, ok := renderers[opts.Format]; !ok {
+		return opts, fmt.Errorf("unknown format %q, expected one of %s", opts.Format, strings.Join(formatNames(), ", "))
+	}
+	include, err := parseInclude(c.Query("include"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Include = include
+	return opts, nil
+}
+
+func (o viewOptions) apply(s dluparser.Schedule) (dluparser.Schedule, any, error) {
+	if o.Day != "" {
+		var err error
+		if s, err = filterDay(s, o.Day); err != nil {
+			return s, nil, err
+		}
+	}
+	if o.Session != "" || o.Teacher != "" || o.Subject != "" {
+		session := -1
+		if o.Session != "" {
+			session, _ = sessionIndex(o.Session)
+		}
+		teacher, subject := folded(o.Teacher), folded(o.Subject)
+		s = filterSubjects(s, session, func(sub dluparser.Subject) bool {
+			return strings.Contains(folded(sub.Teacher), teacher) &&
+				(strings.Contains(folded(sub.Name), subject) || strings.Contains(folded(sub.Code), subject))
+		})
+	}
+	if o.Include["summary"] {
+		s.Summary = summarize(s)
+	}
+	if o.Include["conflicts"] {
+		s.Conflicts = dluparser.Conflicts(s)
+	}
+	if o.View == "matrix" {
+		return s, matrixView(s), nil
+	}
+	shown := s
+	if !o.Raw {
+		shown = withoutRawLessons(s)
+	}
+	var view any
+	var err error
+	if o.Lang == "en" {
+		view = englishSchedule(shown)
+	} else if view, err = project(shown, o.Projection); err != nil {
+		return s, nil, err
+	}
+	if len(o.Fields) > 0 {
+		view, err = selectFields(view, o.Fields)
+	}
+	return s, view, err
+}
+
+// writeSchedule renders a schedule according to opts, tagging the response
+// with an ETag of the exact bytes sent.
+func writeSchedule(c *gin.Context, s dluparser.Schedule, opts viewOptions) {
+	s, view, err := opts.apply(s)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	r := renderers[opts.Format]
+	body, err := r.Render(s, view)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if opts.Negotiated {
+		c.Header("Vary", "Accept")
+	}
+	if r.Extension != "" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, downloadName(s, r.Extension)))
+	}
+	if s.Meta != nil && s.Meta.Cache != "" {
+		c.Header("X-Cache", s.Meta.Cache)
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, r.ContentType, body)
+}
+
+// downloadName is e.g. "CTK45-tuan-38.csv".
+func downloadName(s dluparser.Schedule, ext string) string {
+	name := classIDRe.FindString(s.Class)
+	if name == "" {
+		name = "tkb"
+	}
+	if s.Week != "" {
+		name += "-tuan-" + s.Week
+	}
+	return name + "." + ext
+}
+
+// scheduleHeading is the title and subtitle the document formats print above
+// the week.
+func scheduleHeading(s dluparser.Schedule) (title, subtitle string) {
+	title = strings.TrimSpace(fmt.Sprintf("Thời khóa biểu lớp %s – Tuần %s", s.Class, s.Week))
+	var parts []string
+	if s.Meta != nil {
+		parts = append(parts, strings.TrimSpace(s.Meta.YearStudy+" "+s.Meta.TermID))
+	}
+	if !s.WeekStart.IsZero() {
+		parts = append(parts, s.WeekStart.Format("02/01/2006")+" – "+s.WeekStart.AddDate(0, 0, 6).Format("02/01/2006"))
+	}
+	return title, strings.Join(parts, " · ")
+}
+
+// daysByIndex maps Monday=1 … Sunday=7 to the week's days, for renderers that
+// always draw all seven.
+func daysByIndex(s dluparser.Schedule) map[int]dluparser.Day {
+	days := make(map[int]dluparser.Day)
+	for _, day := range s.OrderedDays() {
+		if n, ok := dluparser.WeekdayIndex(day.VietnameseName); ok {
+			days[n] = day
+		}
+	}
+	return days
+}
+
+// shortDate turns "2006-01-02" into "02/01".
+func shortDate(date string) string {
+	if len(date) < 10 {
+		return date
+	}
+	return date[8:10] + "/" + date[5:7]
+}
+
+// wrapLines breaks s into lines no wider than width as measured, splitting
+// words only when a single word doesn't fit.
+func wrapLines(s string, width float64, measure func(string) float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := strings.TrimSpace(line + " " + word)
+		if measure(candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = ""
+		for _, r := range word {
+			if measure(line+string(r)) > width && line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"dlu-api/pkg/dluparser"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	prev := defaultFormat
+	t.Cleanup(func() { defaultFormat = prev })
+
+	tests := []struct {
+		def, accept, want string
+	}{
+		{"json", "", "json"},
+		{"json", "*/*", "json"},
+		{"json", "text/html,application/xhtml+xml,*/*;q=0.8", "html"},
+		{"json", "text/csv;q=0.5, text/calendar;q=0.9", "ics"},
+		{"ics", "", "ics"},
+		{"ics", "*/*", "ics"},
+		{"ics", "image/webp", "ics"},
+		{"ics", "application/json", "json"},
+		{"ics", "text/csv", "csv"},
+	}
+	for _, tt := range tests {
+		defaultFormat = tt.def
+		if got := negotiateFormat(tt.accept); got != tt.want {
+			t.Errorf("default %s, Accept %q: got %s, want %s", tt.def, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentFormats(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	tests := []struct {
+		path, contentType, magic string
+	}{
+		{"/dlu/pdf", "application/pdf", "%PDF-"},
+		{"/dlu/png", "image/png", "\x89PNG"},
+	}
+	for _, tt := range tests {
+		rec := get(t, h, tt.path+"?"+weekParams+"&ClassStudentID=CTK45")
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status %d: %s", tt.path, rec.Code, rec.Body)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+			t.Errorf("%s: Content-Type %q", tt.path, ct)
+		}
+		if !bytes.HasPrefix(rec.Body.Bytes(), []byte(tt.magic)) {
+			t.Errorf("%s: body starts %q", tt.path, rec.Body.Bytes()[:min(8, rec.Body.Len())])
+		}
+	}
+}
+
+func TestDayFilter(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	tests := []struct {
+		day  string
+		want int
+		// days are the Vietnamese names returned, or the error message.
+		days []string
+		err  string
+	}{
+		{"Thứ 2", http.StatusOK, []string{"Thứ 2"}, ""},
+		{"wednesday", http.StatusOK, []string{"Thứ 4"}, ""},
+		{"Thứ 6", http.StatusOK, nil, ""},
+		{"Thứ", http.StatusBadRequest, nil, `unrecognized day "Thứ"`},
+		{"funday", http.StatusBadRequest, nil, `unrecognized day "funday"`},
+	}
+	for _, tt := range tests {
+		rec := get(t, h, "/dlu?"+weekParams+"&ClassStudentID=CTK45&day="+url.QueryEscape(tt.day))
+		if rec.Code != tt.want {
+			t.Errorf("day %q: status %d, want %d: %s", tt.day, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			var body struct{ Error string }
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != tt.err {
+				t.Errorf("day %q: error %q, want %q", tt.day, body.Error, tt.err)
+			}
+			continue
+		}
+		var s dluparser.Schedule
+		if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+			t.Fatal(err)
+		}
+		var days []string
+		for name := range s.Days {
+			days = append(days, name)
+		}
+		if !slices.Equal(days, tt.days) {
+			t.Errorf("day %q: got days %v, want %v", tt.day, days, tt.days)
+		}
+	}
+}
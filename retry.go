@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	retryAttempts   = envInt("DLU_UPSTREAM_RETRIES", 3)
+	retryBackoff    = envDuration("DLU_UPSTREAM_BACKOFF", 200*time.Millisecond)
+	retryMaxBackoff = envDuration("DLU_UPSTREAM_MAX_BACKOFF", 2*time.Second)
+)
+
+// statusError is a non-2xx upstream response.
+type statusError struct {
+	Code   int
+	Status string
+	// RetryAfter is the response's Retry-After, if it had one.
+	RetryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("upstream returned %s", e.Status)
+}
+
+// retryError is a fetch that still failed after Attempts tries. LastStatus
+// is the portal's last HTTP status, 0 when it never answered.
+type retryError struct {
+	Attempts   int
+	LastStatus int
+	Err        error
+}
+
+func (e *retryError) Error() string {
+	if e.Attempts == 1 {
+		return fmt.Sprintf("%v (1 attempt)", e.Err)
+	}
+	return fmt.Sprintf("%v (%d attempts)", e.Err, e.Attempts)
+}
+
+func (e *retryError) Unwrap() error { return e.Err }
+
+// parseRetryAfter reads a Retry-After header given either as seconds or as
+// an HTTP date, returning 0 when it is missing or already past.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// transient reports whether a failed fetch is worth repeating: network
+// errors, 5xx responses and 429s are; other 4xx responses and our own
+// cancellations are not.
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.Code >= 500 || se.Code == http.StatusTooManyRequests
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// backoff is the wait before retry n (1-based): exponential from
+// DLU_UPSTREAM_BACKOFF up to DLU_UPSTREAM_MAX_BACKOFF, with full jitter so
+// clients retrying together spread out.
+func backoff(n int) time.Duration {
+	d := retryBackoff << (n - 1)
+	if d <= 0 || d > retryMaxBackoff {
+		d = retryMaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(d) + 1))
+}
+
+// withRetry calls fetch up to DLU_UPSTREAM_RETRIES times while it fails
+// transiently, returning the number of attempts made. A Retry-After on the
+// failed response replaces the backoff; when it is longer than ctx has left,
+// withRetry gives up so the caller can pass the wait on.
+func withRetry(ctx context.Context, fetch func() ([]byte, error)) ([]byte, int, error) {
+	for attempt := 1; ; attempt++ {
+		body, err := fetch()
+		if err == nil || attempt >= retryAttempts || !transient(err) {
+			return body, attempt, err
+		}
+		wait := backoff(attempt)
+		var se *statusError
+		if errors.As(err, &se) && se.RetryAfter > 0 {
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < se.RetryAfter {
+				return nil, attempt, err
+			}
+			wait = se.RetryAfter
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, attempt, err
+		}
+	}
+}
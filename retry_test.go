@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUpstreamRateLimited(t *testing.T) {
+	prevAttempts := retryAttempts
+	t.Cleanup(func() { retryAttempts = prevAttempts })
+	retryAttempts = 2
+	page := servePage(t, "mau2.html")
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		// throttled is how many requests get a 429 before the page is served.
+		throttled     int32
+		want          int
+		hits          int32
+		retryAfterOut string
+		minElapsed    time.Duration
+	}{
+		{"waits out Retry-After", "1", 1, http.StatusOK, 2, "", time.Second},
+		{"passes on 429 after the last attempt", "1", 100, http.StatusTooManyRequests, 2, "1", time.Second},
+		{"passes on a wait longer than the timeout", "120", 100, http.StatusTooManyRequests, 1, "120", 0},
+	}
+	for _, tt := range tests {
+		var hits atomic.Int32
+		h := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if hits.Add(1) <= tt.throttled {
+				w.Header().Set("Retry-After", tt.retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			page(w, r)
+		})
+		start := time.Now()
+		rec := get(t, h, "/dlu?"+weekParams+"&ClassStudentID=CTK45")
+		elapsed := time.Since(start)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if got := hits.Load(); got != tt.hits {
+			t.Errorf("%s: %d upstream requests, want %d", tt.name, got, tt.hits)
+		}
+		if got := rec.Header().Get("Retry-After"); got != tt.retryAfterOut {
+			t.Errorf("%s: Retry-After %q, want %q", tt.name, got, tt.retryAfterOut)
+		}
+		if elapsed < tt.minElapsed {
+			t.Errorf("%s: answered after %s, before Retry-After ran out", tt.name, elapsed)
+		}
+		if tt.want == http.StatusOK {
+			var s dluparser.Schedule
+			if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+				t.Fatal(err)
+			}
+			if s.Meta == nil || s.Meta.Attempts != 2 {
+				t.Errorf("%s: meta %+v, want 2 attempts", tt.name, s.Meta)
+			}
+		}
+	}
+}
+
+func TestUpstreamErrorAttempts(t *testing.T) {
+	prevAttempts, prevBackoff := retryAttempts, retryBackoff
+	t.Cleanup(func() { retryAttempts, retryBackoff = prevAttempts, prevBackoff })
+	retryAttempts, retryBackoff = 3, time.Millisecond
+
+	tests := []struct {
+		name     string
+		status   int
+		want     int
+		attempts int
+	}{
+		{"retries a 502", http.StatusBadGateway, http.StatusInternalServerError, 3},
+		{"gives up on a 404", http.StatusNotFound, http.StatusInternalServerError, 1},
+	}
+	for _, tt := range tests {
+		h := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tt.status)
+		})
+		rec := get(t, h, "/dlu?"+weekParams+"&ClassStudentID=CTK45")
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		var body struct {
+			Error          string `json:"error"`
+			Attempts       int    `json:"attempts"`
+			UpstreamStatus int    `json:"upstream_status"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Attempts != tt.attempts || body.UpstreamStatus != tt.status {
+			t.Errorf("%s: got %+v, want %d attempts and upstream status %d", tt.name, body, tt.attempts, tt.status)
+		}
+		if !strings.Contains(body.Error, http.StatusText(tt.status)) {
+			t.Errorf("%s: error %q doesn't name the upstream status", tt.name, body.Error)
+		}
+	}
+}
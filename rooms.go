@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// roomsCacheTTL bounds how long a crawled week is reused by the room finder. A
+// crawl touches every configured class, so repeating it per request would
+// hammer the upstream.
+var roomsCacheTTL = envDuration("DLU_ROOMS_CACHE_TTL", 10*time.Minute)
+
+type crawledWeek struct {
+	Schedules []dluparser.Schedule
+	Errors    map[string]string
+	At        time.Time
+}
+
+var (
+	crawlMu    sync.Mutex
+	crawlCache = make(map[string]crawledWeek)
+)
+
+// allClasses is every class named in DLU_CLASS_GROUPS, deduplicated.
+func allClasses() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, group := range classGroups {
+		for _, id := range group {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// crawlWeek fetches a week for every class in ids, answering from the
+// crawler's index when it has the week, or else reusing a recent crawl of
+// the same week and class set when there is one.
+func crawlWeek(ctx context.Context, q scheduleQuery, ids []string) crawledWeek {
+	if w, ok := crawlIndex.lookup(q, ids); ok {
+		return w
+	}
+	key := strings.Join([]string{q.YearStudy, q.TermID, q.Week, strings.Join(ids, ",")}, "|")
+
+	crawlMu.Lock()
+	if w, ok := crawlCache[key]; ok && time.Since(w.At) < roomsCacheTTL {
+		crawlMu.Unlock()
+		return w
+	}
+	crawlMu.Unlock()
+
+	w := crawledWeek{Errors: map[string]string{}, At: time.Now()}
+	for id, res := range fetchClasses(ctx, q, ids) {
+		if res.Err != nil {
+			w.Errors[id] = res.Err.Error()
+			continue
+		}
+		w.Schedules = append(w.Schedules, res.Schedule)
+	}
+
+	crawlMu.Lock()
+	for k, old := range crawlCache {
+		if time.Since(old.At) >= roomsCacheTTL {
+			delete(crawlCache, k)
+		}
+	}
+	crawlCache[key] = w
+	crawlMu.Unlock()
+	return w
+}
+
+// roomSchedule assembles a week for one room out of class schedules, with a
+// session shared by merged classes listed once. The room goes in Class,
+// spelled as the timetables have it.
+func roomSchedule(schedules []dluparser.Schedule, q scheduleQuery, room string) dluparser.Schedule {
+	out := dluparser.Schedule{
+		Class: room,
+		Week:  q.Week,
+		Days:  map[string]dluparser.DaySchedule{},
+		Meta:  &dluparser.Meta{YearStudy: q.YearStudy, TermID: q.TermID, Semester: q.Semester},
+	}
+	seen := make(map[string]bool)
+	for _, s := range schedules {
+		if out.WeekStart.IsZero() {
+			out.WeekStart = s.WeekStart
+		}
+		for _, day := range s.OrderedDays() {
+			d := out.Days[day.VietnameseName]
+			for i, slot := range [][]dluparser.Subject{day.Sang, day.Chieu, day.Toi} {
+				for _, sub := range slot {
+					key := strings.Join([]string{day.VietnameseName, sub.Period, sub.Name, sub.Group}, "\x00")
+					if !strings.EqualFold(sub.Room, room) || seen[key] {
+						continue
+					}
+					seen[key] = true
+					out.Class = sub.Room
+					switch i {
+					case 0:
+						d.Sang = append(d.Sang, sub)
+					case 1:
+						d.Chieu = append(d.Chieu, sub)
+					case 2:
+						d.Toi = append(d.Toi, sub)
+					}
+				}
+			}
+			if len(d.Sang)+len(d.Chieu)+len(d.Toi) > 0 {
+				out.Days[day.VietnameseName] = d
+			}
+		}
+	}
+	for _, d := range out.Days {
+		for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			sort.SliceStable(slot, func(i, j int) bool { return slot[i].PeriodStart < slot[j].PeriodStart })
+		}
+	}
+	return out
+}
+
+// roomScheduleHandler returns everything held in a room during a week, in
+// the shape and output options of /dlu. Classes that couldn't be crawled are
+// reported as warnings, since their sessions may be missing.
+func roomScheduleHandler(c *gin.Context) {
+	opts, err := bindViewOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, w, ok := crawlDiscovered(c)
+	if !ok {
+		return
+	}
+
+	s := roomSchedule(w.Schedules, q, strings.TrimSpace(c.Param("room")))
+	ids := make([]string, 0, len(w.Errors))
+	for id := range w.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		s.Warnings = append(s.Warnings, dluparser.Warning{
+			Code:    "classUnavailable",
+			Message: fmt.Sprintf("class %s could not be fetched: %s", id, w.Errors[id]),
+		})
+	}
+	writeSchedule(c, s, opts)
+}
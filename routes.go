@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// route is the single source of truth for what the server exposes: main
+// registers handlers from it and /dlu/capabilities describes it.
+type route struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	Query    []string `json:"query,omitempty"`
+	Admin    bool     `json:"admin,omitempty"`
+	handlers []gin.HandlerFunc
+	// response is a value of the JSON body type, for /openapi.json.
+	response any
+}
+
+var weekQuery = []string{"YearStudy", "TermID", "Week", "semester"}
+
+func withWeek(extra ...string) []string {
+	return append(append([]string{}, weekQuery...), extra...)
+}
+
+var routes []route
+
+func init() {
+	routes = []route{
+		{Method: http.MethodGet, Path: "/dlu", Query: withWeek("ClassStudentID", "projection", "day", "session", "teacher", "subject", "include", "view", "format", "lang", "fields", "raw"), handlers: []gin.HandlerFunc{scheduleHandler}, response: dluparser.Schedule{}},
+		{Method: http.MethodGet, Path: "/u/:provider/schedule", Query: withWeek("ClassStudentID", "projection", "day", "session", "teacher", "subject", "include", "view", "format", "lang", "fields", "raw"), handlers: []gin.HandlerFunc{providerScheduleHandler}, response: dluparser.Schedule{}},
+		{Method: http.MethodGet, Path: "/dlu/ics", Query: withWeek("ClassStudentID", "day", "session", "teacher", "subject"), handlers: []gin.HandlerFunc{formatHandler("ics")}},
+		{Method: http.MethodGet, Path: "/dlu/csv", Query: withWeek("ClassStudentID", "day", "session", "teacher", "subject"), handlers: []gin.HandlerFunc{formatHandler("csv")}},
+		{Method: http.MethodGet, Path: "/dlu/xlsx", Query: withWeek("ClassStudentID", "day", "session", "teacher", "subject"), handlers: []gin.HandlerFunc{formatHandler("xlsx")}},
+		{Method: http.MethodGet, Path: "/dlu/pdf", Query: withWeek("ClassStudentID", "day", "session", "teacher", "subject"), handlers: []gin.HandlerFunc{formatHandler("pdf")}},
+		{Method: http.MethodGet, Path: "/dlu/png", Query: withWeek("ClassStudentID", "day", "session", "teacher", "subject"), handlers: []gin.HandlerFunc{formatHandler("png")}},
+		{Method: http.MethodGet, Path: "/dlu/embed", Query: withWeek("ClassStudentID", "day", "session", "teacher", "subject"), handlers: []gin.HandlerFunc{formatHandler("html")}},
+		{Method: http.MethodGet, Path: "/dlu/batch", Query: withWeek("ClassStudentID"), handlers: []gin.HandlerFunc{batchHandler}},
+		{Method: http.MethodPost, Path: "/dlu/batch", handlers: []gin.HandlerFunc{batchHandler}},
+		{Method: http.MethodGet, Path: "/dlu/teacher", Query: withWeek("TeacherID", "projection", "day", "session", "teacher", "subject", "include", "view", "format", "lang", "fields", "raw"), handlers: []gin.HandlerFunc{teacherHandler}, response: dluparser.Schedule{}},
+		{Method: http.MethodGet, Path: "/dlu/teachers/:name/load", Query: weekQuery, handlers: []gin.HandlerFunc{teacherLoadHandler}, response: teacherLoad{}},
+		{Method: http.MethodGet, Path: "/dlu/search", Query: withWeek("teacher", "room", "subject"), handlers: []gin.HandlerFunc{searchHandler}, response: searchResponse{}},
+		{Method: http.MethodGet, Path: "/dlu/freeslots", Query: withWeek("ClassStudentID"), handlers: []gin.HandlerFunc{freeSlotsHandler}, response: freeSlotsResponse{}},
+		{Method: http.MethodGet, Path: "/dlu/freeslots/common", Query: withWeek("ClassStudentID"), handlers: []gin.HandlerFunc{commonFreeSlotsHandler}, response: commonFreeResponse{}},
+		{Method: http.MethodGet, Path: "/dlu/commute", Query: withWeek("ClassStudentID"), handlers: []gin.HandlerFunc{commuteHandler}, response: commuteResponse{}},
+		{Method: http.MethodGet, Path: "/dlu/semester", Query: []string{"YearStudy", "TermID", "semester", "ClassStudentID", "fromWeek", "toWeek", "weeks", "stream"}, handlers: []gin.HandlerFunc{weekRangeHandler}, response: weekRangeResponse{}},
+		{Method: http.MethodGet, Path: "/dlu/remaining", Query: []string{"YearStudy", "TermID", "semester", "ClassStudentID", "fromWeek", "toWeek", "weeks", "near", "many"}, handlers: []gin.HandlerFunc{remainingHandler}, response: remainingResponse{}},
+		{Method: http.MethodGet, Path: "/dlu/calendar", Query: []string{"YearStudy", "TermID", "semester"}, handlers: []gin.HandlerFunc{calendarHandler}},
+		{Method: http.MethodGet, Path: "/dlu/options", Query: []string{"YearStudy", "TermID", "semester"}, handlers: []gin.HandlerFunc{optionsHandler}},
+		{Method: http.MethodGet, Path: "/dlu/classes", Query: []string{"YearStudy", "TermID", "semester"}, handlers: []gin.HandlerFunc{classesHandler}},
+		{Method: http.MethodGet, Path: "/dlu/classes/search", Query: []string{"q", "limit", "YearStudy", "TermID", "semester"}, handlers: []gin.HandlerFunc{classSearchHandler}},
+		{Method: http.MethodGet, Path: "/dlu/exams", Query: []string{"YearStudy", "TermID", "semester", "ClassStudentID"}, handlers: []gin.HandlerFunc{examsHandler}},
+		{Method: http.MethodGet, Path: "/dlu/find-room", Query: withWeek("group", "Day", "Period", "day", "period"), handlers: []gin.HandlerFunc{findRoomHandler}},
+		{Method: http.MethodGet, Path: "/dlu/rooms", Query: withWeek("group", "Day", "Period", "day", "period"), handlers: []gin.HandlerFunc{findRoomHandler}},
+		{Method: http.MethodGet, Path: "/dlu/rooms/:room/schedule", Query: withWeek("projection", "day", "session", "teacher", "subject", "include", "view", "format", "lang", "fields", "raw"), handlers: []gin.HandlerFunc{roomScheduleHandler}, response: dluparser.Schedule{}},
+		{Method: http.MethodGet, Path: "/dlu/faculty", Query: withWeek("group"), Admin: true, handlers: []gin.HandlerFunc{requireAdmin, facultyHandler}},
+		{Method: http.MethodGet, Path: "/dlu/validate", Query: withWeek("ClassStudentID"), handlers: []gin.HandlerFunc{validateHandler}},
+		{Method: http.MethodPost, Path: "/dlu/parse/bulk", handlers: []gin.HandlerFunc{bulkParseHandler}, response: []bulkParseResult{}},
+		{Method: http.MethodGet, Path: "/dlu/history", Query: withWeek("ClassStudentID"), handlers: []gin.HandlerFunc{historyHandler}},
+		{Method: http.MethodGet, Path: "/dlu/diff", Query: []string{"from", "to"}, handlers: []gin.HandlerFunc{diffHandler}},
+		{Method: http.MethodGet, Path: "/dlu/stream", Query: withWeek("ClassStudentID"), handlers: []gin.HandlerFunc{streamHandler}},
+		{Method: http.MethodGet, Path: "/ws", handlers: []gin.HandlerFunc{wsHandler}},
+		{Method: http.MethodGet, Path: "/subscriptions", handlers: []gin.HandlerFunc{requireSubscriber, listSubscriptionsHandler}},
+		{Method: http.MethodPost, Path: "/subscriptions", handlers: []gin.HandlerFunc{requireSubscriber, createSubscriptionHandler}},
+		{Method: http.MethodDelete, Path: "/subscriptions/:id", handlers: []gin.HandlerFunc{requireSubscriber, deleteSubscriptionHandler}},
+		{Method: http.MethodGet, Path: "/healthz", handlers: []gin.HandlerFunc{healthzHandler}},
+		{Method: http.MethodGet, Path: "/readyz", handlers: []gin.HandlerFunc{readyzHandler}},
+		{Method: http.MethodGet, Path: "/dlu/capabilities", handlers: []gin.HandlerFunc{capabilitiesHandler}},
+		{Method: http.MethodGet, Path: "/openapi.json", handlers: []gin.HandlerFunc{openAPIHandler}},
+		{Method: http.MethodGet, Path: "/docs", handlers: []gin.HandlerFunc{docsHandler}},
+		{Method: http.MethodGet, Path: "/admin/keys", Admin: true, handlers: []gin.HandlerFunc{requireAdmin, listKeysHandler}},
+		{Method: http.MethodPost, Path: "/admin/keys", Admin: true, handlers: []gin.HandlerFunc{requireAdmin, createKeyHandler}},
+		{Method: http.MethodDelete, Path: "/admin/keys/:id", Admin: true, handlers: []gin.HandlerFunc{requireAdmin, revokeKeyHandler}},
+		{Method: http.MethodGet, Path: "/dlu/debug/html", Query: withWeek("ClassStudentID"), Admin: true, handlers: []gin.HandlerFunc{requireAdmin, debugHTMLHandler}},
+	}
+	routes = append(routes, v1Routes()...)
+}
+
+// stripTrailingSlash lets "/dlu/" and "/dlu" reach the same handler without
+// a redirect, since many API clients won't follow one for non-GET requests.
+func stripTrailingSlash(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if p := req.URL.Path; len(p) > 1 && strings.HasSuffix(p, "/") {
+			req.URL.Path = strings.TrimRight(p, "/")
+			if req.URL.Path == "" {
+				req.URL.Path = "/"
+			}
+			req.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func registerRoutes(r gin.IRouter) {
+	for _, rt := range routes {
+		r.Handle(rt.Method, rt.Path, rt.handlers...)
+	}
+}
+
+var projections = []string{"full", "slim"}
+
+func featureFlags() map[string]bool {
+	return map[string]bool{
+		"semesterLabels": true,
+		"roomFinder":     len(classGroups) > 0,
+		"adminDebug":     adminKey != "",
+		"exams":          examURL != "",
+		"teacherView":    teacherURL != "",
+		"webhooks":       adminKey != "" || apiKeysFile != "",
+		"streams":        true,
+		"telegramBot":    botToken != "",
+		"history":        historyDir != "",
+		"facultyView":    adminKey != "" && len(classGroups) > 0,
+		"apiKeys":        apiKeysFile != "" && adminKey != "",
+		"portalCalendar": optionsURL != "",
+		"portalOptions":  optionsURL != "",
+		"crawler":        crawlSemester != "",
+	}
+}
+
+func capabilitiesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"formats":     formatNames(),
+		"projections": projections,
+		"routes":      routes,
+		"features":    featureFlags(),
+		"providers":   providerNames(),
+	})
+}
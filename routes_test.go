@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTrailingSlash(t *testing.T) {
+	h := newTestServer(t, servePage(t, "mau2.html"))
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/dlu/capabilities", "", http.StatusOK},
+		{http.MethodGet, "/dlu/capabilities/", "", http.StatusOK},
+		{http.MethodGet, "/dlu?" + weekParams + "&ClassStudentID=CTK45", "", http.StatusOK},
+		{http.MethodGet, "/dlu/?" + weekParams + "&ClassStudentID=CTK45", "", http.StatusOK},
+		{http.MethodPost, "/dlu/parse/bulk", `[]`, http.StatusOK},
+		{http.MethodPost, "/dlu/parse/bulk/", `[]`, http.StatusOK},
+		{http.MethodGet, "/nope/", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		// Either form is answered directly, never with a redirect.
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// classSession is one timetabled session found across class schedules, with
+// every class attending it.
+type classSession struct {
+	Day       string   `json:"day"`
+	Date      string   `json:"date,omitempty"`
+	Slot      string   `json:"slot"`
+	Period    string   `json:"tiet"`
+	StartTime string   `json:"gio_bat_dau,omitempty"`
+	EndTime   string   `json:"gio_ket_thuc,omitempty"`
+	Subject   string   `json:"ten_mon"`
+	Code      string   `json:"ma_mon,omitempty"`
+	Group     string   `json:"nhom"`
+	Room      string   `json:"phong"`
+	Teacher   string   `json:"gv"`
+	Classes   []string `json:"classes"`
+
+	day, start, periods int
+}
+
+// collectSessions gathers the sessions keep accepts, in day and period
+// order. A session shared by merged classes appears in each of their
+// timetables but is held once, so it is listed once with all its classes.
+func collectSessions(schedules []dluparser.Schedule, keep func(dluparser.Subject) bool) []classSession {
+	sessions := []classSession{}
+	index := make(map[string]int)
+	for _, s := range schedules {
+		for _, day := range s.OrderedDays() {
+			n, _ := dluparser.WeekdayIndex(day.VietnameseName)
+			for i, slot := range [][]dluparser.Subject{day.Sang, day.Chieu, day.Toi} {
+				for _, sub := range slot {
+					if !keep(sub) {
+						continue
+					}
+					key := strings.Join([]string{day.VietnameseName, sub.Period, sub.Room, sub.Name, sub.Group}, "\x00")
+					if j, ok := index[key]; ok {
+						if cs := &sessions[j]; !slices.Contains(cs.Classes, s.Class) {
+							cs.Classes = append(cs.Classes, s.Class)
+						}
+						continue
+					}
+					index[key] = len(sessions)
+					sessions = append(sessions, classSession{
+						Day: day.VietnameseName, Date: day.Date, Slot: dluparser.Slots[i],
+						Period: sub.Period, StartTime: sub.StartTime, EndTime: sub.EndTime,
+						Subject: sub.Name, Code: sub.Code, Group: sub.Group, Room: sub.Room, Teacher: sub.Teacher,
+						Classes: []string{s.Class},
+						day:     n, start: sub.PeriodStart, periods: len(sub.Periods),
+					})
+				}
+			}
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].day != sessions[j].day {
+			return sessions[i].day < sessions[j].day
+		}
+		return sessions[i].start < sessions[j].start
+	})
+	return sessions
+}
+
+// crawlDiscovered loads a week for every discovered class, from the crawler's
+// index when it has the week. It writes the error response itself and
+// reports false on failure.
+func crawlDiscovered(c *gin.Context) (scheduleQuery, crawledWeek, bool) {
+	q, err := bindWeekQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return q, crawledWeek{}, false
+	}
+	ids, err := discoveredClassIDs(c.Request.Context(), q)
+	if errors.Is(err, errClassesNotConfigured) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return q, crawledWeek{}, false
+	}
+	if err != nil {
+		upstreamError(c, err)
+		return q, crawledWeek{}, false
+	}
+	return q, crawlWeek(c.Request.Context(), q, ids), true
+}
+
+// courseGroup is one group of a course, for finding an alternative group to
+// attend: its classes and every session it meets.
+type courseGroup struct {
+	Subject  string         `json:"ten_mon"`
+	Code     string         `json:"ma_mon,omitempty"`
+	Group    string         `json:"nhom"`
+	Classes  []string       `json:"classes"`
+	Periods  int            `json:"periods"`
+	Sessions []classSession `json:"sessions"`
+}
+
+// courseGroups arranges sessions by course group, sorted by subject and
+// group number. Subject searches include them so a student retaking a course
+// can compare the groups on offer.
+func courseGroups(sessions []classSession) []courseGroup {
+	var groups []courseGroup
+	index := make(map[string]int)
+	for _, cs := range sessions {
+		course := cs.Code
+		if course == "" {
+			course = folded(cs.Subject)
+		}
+		key := course + "\x00" + cs.Group
+		j, ok := index[key]
+		if !ok {
+			j = len(groups)
+			index[key] = j
+			groups = append(groups, courseGroup{Subject: cs.Subject, Code: cs.Code, Group: cs.Group})
+		}
+		g := &groups[j]
+		for _, class := range cs.Classes {
+			if !slices.Contains(g.Classes, class) {
+				g.Classes = append(g.Classes, class)
+			}
+		}
+		g.Periods += cs.periods
+		g.Sessions = append(g.Sessions, cs)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Subject != groups[j].Subject {
+			return groups[i].Subject < groups[j].Subject
+		}
+		a, errA := strconv.Atoi(groups[i].Group)
+		b, errB := strconv.Atoi(groups[j].Group)
+		if errA != nil || errB != nil {
+			return groups[i].Group < groups[j].Group
+		}
+		return a < b
+	})
+	return groups
+}
+
+type searchResponse struct {
+	Week      string            `json:"week"`
+	Count     int               `json:"count"`
+	Sessions  []classSession    `json:"sessions"`
+	Groups    []courseGroup     `json:"groups,omitempty"`
+	Indexed   bool              `json:"indexed"`
+	CrawledAt string            `json:"crawled_at"`
+	Errors    map[string]string `json:"errors"`
+}
+
+// searchHandler finds sessions across every class by teacher, room or
+// subject; given several, a session has to match all of them. Teacher and
+// subject match part of the name ignoring case and accents, rooms match
+// whole.
+func searchHandler(c *gin.Context) {
+	teacher, subject := folded(c.Query("teacher")), folded(c.Query("subject"))
+	room := strings.TrimSpace(c.Query("room"))
+	if teacher == "" && subject == "" && room == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "teacher, room or subject is required"})
+		return
+	}
+	q, w, ok := crawlDiscovered(c)
+	if !ok {
+		return
+	}
+	_, indexed := crawlIndex.week(q)
+
+	sessions := collectSessions(w.Schedules, func(sub dluparser.Subject) bool {
+		return strings.Contains(folded(sub.Teacher), teacher) &&
+			(strings.Contains(folded(sub.Name), subject) || strings.Contains(folded(sub.Code), subject)) &&
+			(room == "" || strings.EqualFold(sub.Room, room))
+	})
+	resp := searchResponse{
+		Week:      q.Week,
+		Count:     len(sessions),
+		Sessions:  sessions,
+		Indexed:   indexed,
+		CrawledAt: w.At.UTC().Format(time.RFC3339),
+		Errors:    w.Errors,
+	}
+	if subject != "" {
+		resp.Groups = courseGroups(sessions)
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,25 @@
+package main
+
+import "dlu-api/pkg/dluparser"
+
+// htmlParser starts out with the default selectors; checkConfig replaces it
+// with the configured set after validating it.
+var htmlParser, _ = dluparser.NewHTMLParser(dluparser.DefaultSelectors)
+
+// loadSelectors applies <prefix>HEADER, ROWS, DAY and SLOT overrides
+// (DLU_SELECTOR_* for DLU), which let operators follow small markup changes
+// on a portal without a rebuild.
+func loadSelectors(prefix string) dluparser.Selectors {
+	s := dluparser.DefaultSelectors
+	for env, field := range map[string]*string{
+		"HEADER": &s.Header,
+		"ROWS":   &s.Rows,
+		"DAY":    &s.Day,
+		"SLOT":   &s.Slot,
+	} {
+		if v := getenv(prefix + env); v != "" {
+			*field = v
+		}
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Semester struct {
+	YearStudy string
+	TermID    string
+}
+
+var semesterLabelRe = regexp.MustCompile(`(?i)^(?:hk|học\s*kỳ)\s*(\d)\s*[-,\s]\s*(\d{4})(?:\s*-\s*(\d{4}))?$`)
+
+// semesterTable holds operator-configured overrides from DLU_SEMESTERS, e.g.
+// "HK1-2024=2024-2025/HK01;HK3-2024=2024-2025/HK03".
+var semesterTable = loadSemesterTable(getenv("DLU_SEMESTERS"))
+
+func loadSemesterTable(raw string) map[string]Semester {
+	table := make(map[string]Semester)
+	for _, entry := range strings.Split(raw, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		label, codes, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		year, term, ok2 := strings.Cut(codes, "/")
+		if !ok || !ok2 {
+			configError("DLU_SEMESTERS entry %q: expected LABEL=YEAR/TERM", entry)
+			continue
+		}
+		table[strings.ToUpper(strings.TrimSpace(label))] = Semester{
+			YearStudy: strings.TrimSpace(year),
+			TermID:    strings.TrimSpace(term),
+		}
+	}
+	return table
+}
+
+// resolveSemester maps labels such as "HK1-2024" or "Học kỳ 1, 2024-2025" to
+// the YearStudy/TermID pair the upstream expects.
+func resolveSemester(label string) (Semester, error) {
+	label = strings.TrimSpace(label)
+	if s, ok := semesterTable[strings.ToUpper(label)]; ok {
+		return s, nil
+	}
+
+	m := semesterLabelRe.FindStringSubmatch(label)
+	if m == nil {
+		return Semester{}, fmt.Errorf("unrecognized semester %q, expected e.g. HK1-2024", label)
+	}
+
+	start, _ := strconv.Atoi(m[2])
+	if m[3] != "" {
+		end, _ := strconv.Atoi(m[3])
+		if end != start+1 {
+			return Semester{}, fmt.Errorf("invalid academic year %s-%s", m[2], m[3])
+		}
+	}
+
+	return Semester{
+		YearStudy: fmt.Sprintf("%d-%d", start, start+1),
+		TermID:    "HK0" + m[1],
+	}, nil
+}
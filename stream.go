@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	maxStreams   = envInt("DLU_MAX_STREAMS", 1000)
+	streamPing   = envDuration("DLU_STREAM_PING", 30*time.Second)
+	streamBuffer = 8
+)
+
+// watcher is a connected client waiting for changes to one class and week.
+// The subscription poller diffs each fetch against what the watcher last
+// saw, the same way it does for webhooks. A client watching several classes
+// shares one events channel between its watchers, told apart by id.
+type watcher struct {
+	id     string
+	query  scheduleQuery
+	last   dluparser.Schedule
+	events chan changeEvent
+	// follow moves the watcher on to each new week as it starts.
+	follow bool
+}
+
+var (
+	watchMu  sync.Mutex
+	watchers = make(map[*watcher]struct{})
+	// streamsClosing is closed on shutdown so open streams end instead of
+	// holding up the drain.
+	streamsClosing = make(chan struct{})
+)
+
+func addWatcher(w *watcher) bool {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	if len(watchers) >= maxStreams {
+		return false
+	}
+	watchers[w] = struct{}{}
+	return true
+}
+
+func removeWatcher(w *watcher) {
+	watchMu.Lock()
+	delete(watchers, w)
+	watchMu.Unlock()
+}
+
+func watchersByQuery() map[scheduleQuery][]*watcher {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	byQuery := make(map[scheduleQuery][]*watcher)
+	for w := range watchers {
+		byQuery[w.query] = append(byQuery[w.query], w)
+	}
+	return byQuery
+}
+
+// update is called by the poller with a fresh fetch. A client too slow to
+// drain its buffer misses the event; later diffs are still against the latest
+// schedule, so it never gets a change twice.
+func (w *watcher) update(s dluparser.Schedule) {
+	changes := dluparser.Diff(w.last, s)
+	w.last = s
+	if len(changes) == 0 {
+		return
+	}
+	w.send(changeEvent{Event: "schedule.changed", Checksum: s.Meta.Checksum, Changes: changes})
+}
+
+func (w *watcher) send(ev changeEvent) {
+	ev.SubscriptionID = w.id
+	ev.YearStudy, ev.TermID, ev.Week, ev.ClassStudentID = w.query.YearStudy, w.query.TermID, w.query.Week, w.query.ClassStudentID
+	select {
+	case w.events <- ev:
+	default:
+	}
+}
+
+// rollWatchers moves watchers that follow the current week on to the next
+// one once it starts, sending them its schedule. A failed fetch is retried
+// on the next poll.
+func rollWatchers(now time.Time) {
+	watchMu.Lock()
+	due := make(map[*watcher]scheduleQuery)
+	for w := range watchers {
+		if !w.follow {
+			continue
+		}
+		week, err := weekAt(w.query.YearStudy, now)
+		if err == nil && strconv.Itoa(week) != w.query.Week {
+			q := w.query
+			q.Week = strconv.Itoa(week)
+			due[w] = q
+		}
+	}
+	watchMu.Unlock()
+
+	for w, q := range due {
+		s, err := loadSchedule(context.Background(), q)
+		if err != nil {
+			log.Printf("streams: roll %s over to week %s: %v", q.ClassStudentID, q.Week, err)
+			continue
+		}
+		watchMu.Lock()
+		w.query = q
+		watchMu.Unlock()
+		w.last = s
+		w.send(changeEvent{Event: "week.rollover", Checksum: s.Meta.Checksum, Schedule: &s})
+	}
+}
+
+func closeStreams() {
+	close(streamsClosing)
+}
+
+// streamHandler keeps the connection open and sends a Server-Sent Event
+// whenever the poller finds the class's week changed. It starts with a
+// "ready" event carrying the current checksum.
+func streamHandler(c *gin.Context) {
+	q, err := bindScheduleQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule, err := loadSchedule(c.Request.Context(), q)
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+	w := &watcher{query: q, last: schedule, events: make(chan changeEvent, streamBuffer)}
+	if !addWatcher(w) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Stream limit reached"})
+		return
+	}
+	defer removeWatcher(w)
+
+	// The server's write timeout is meant for ordinary responses.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", gin.H{
+		"YearStudy":      q.YearStudy,
+		"TermID":         q.TermID,
+		"Week":           q.Week,
+		"ClassStudentID": q.ClassStudentID,
+		"checksum":       schedule.Meta.Checksum,
+	})
+	c.Writer.Flush()
+
+	ping := time.NewTicker(streamPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-streamsClosing:
+			return
+		case ev := <-w.events:
+			c.SSEvent(ev.Event, ev)
+		case <-ping.C:
+			c.Writer.WriteString(": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+)
+
+var weekdayShort = []string{"", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// summarize renders a one-line description of the week, e.g.
+// "6 classes across Mon, Wed, Fri; free Thu".
+func summarize(s dluparser.Schedule) string {
+	var busy, free []int
+	total := 0
+	for name, d := range s.Days {
+		n, ok := dluparser.WeekdayIndex(name)
+		if !ok {
+			continue
+		}
+		count := len(d.Sang) + len(d.Chieu) + len(d.Toi)
+		total += count
+		if count > 0 {
+			busy = append(busy, n)
+		} else {
+			free = append(free, n)
+		}
+	}
+	sort.Ints(busy)
+	sort.Ints(free)
+
+	if total == 0 {
+		return "No classes this week"
+	}
+
+	noun := "classes"
+	if total == 1 {
+		noun = "class"
+	}
+	summary := fmt.Sprintf("%d %s across %s", total, noun, dayList(busy))
+	if len(free) > 0 {
+		summary += "; free " + dayList(free)
+	}
+	return summary
+}
+
+func dayList(days []int) string {
+	names := make([]string, len(days))
+	for i, d := range days {
+		names[i] = weekdayShort[d]
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseInclude validates the comma-separated ?include= list.
+func parseInclude(raw string) (map[string]bool, error) {
+	include := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if part != "summary" && part != "conflicts" {
+			return nil, fmt.Errorf("unknown include %q", part)
+		}
+		include[part] = true
+	}
+	return include, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// teacherURL is the portal's lecturer-view timetable, which uses the same
+// layout as the class view but is keyed by TeacherID.
+var teacherURL = getenv("DLU_TEACHER_URL")
+
+func teacherHandler(c *gin.Context) {
+	if teacherURL == "" {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Teacher schedule source is not configured"})
+		return
+	}
+
+	teacherID := strings.TrimSpace(c.Query("TeacherID"))
+	if teacherID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMissingParams.Error()})
+		return
+	}
+	if !classIDRe.MatchString(teacherID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid TeacherID " + teacherID})
+		return
+	}
+	q, err := bindWeekQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts, err := bindViewOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	v := url.Values{}
+	v.Set("YearStudy", q.YearStudy)
+	v.Set("TermID", q.TermID)
+	v.Set("Week", q.Week)
+	v.Set("TeacherID", teacherID)
+	body, err := fetchURL(c.Request.Context(), teacherURL+"?"+v.Encode())
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+
+	schedule, err := parseSchedulePage(c.Request.Context(), body, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writeSchedule(c, schedule, opts)
+}
+
+type teacherLoad struct {
+	Teacher   string            `json:"teacher"`
+	Week      string            `json:"week"`
+	Periods   int               `json:"periods"`
+	Sessions  []classSession    `json:"sessions"`
+	Rooms     map[string]int    `json:"rooms"`
+	Classes   map[string]int    `json:"classes"`
+	Crawled   int               `json:"classes_crawled"`
+	CrawledAt string            `json:"crawled_at"`
+	Errors    map[string]string `json:"errors"`
+}
+
+// sameTeacher compares lecturer names ignoring case, accents and spacing.
+func sameTeacher(a, b string) bool {
+	return strings.Join(strings.Fields(folded(a)), " ") == strings.Join(strings.Fields(folded(b)), " ")
+}
+
+// computeTeacherLoad totals a lecturer's week across class schedules. A
+// session taught to merged classes is counted once.
+func computeTeacherLoad(schedules []dluparser.Schedule, name string) teacherLoad {
+	load := teacherLoad{Teacher: name, Rooms: map[string]int{}, Classes: map[string]int{}}
+	load.Sessions = collectSessions(schedules, func(sub dluparser.Subject) bool {
+		return sameTeacher(sub.Teacher, name)
+	})
+	for _, cs := range load.Sessions {
+		load.Teacher = cs.Teacher
+		load.Periods += cs.periods
+		for _, class := range cs.Classes {
+			load.Classes[class] += cs.periods
+		}
+		if cs.Room != "" {
+			load.Rooms[cs.Room] += cs.periods
+		}
+	}
+	return load
+}
+
+// teacherLoadHandler reports how much a lecturer teaches in a week, from a
+// crawl of every discovered class.
+func teacherLoadHandler(c *gin.Context) {
+	q, w, ok := crawlDiscovered(c)
+	if !ok {
+		return
+	}
+	load := computeTeacherLoad(w.Schedules, strings.TrimSpace(c.Param("name")))
+	load.Week = q.Week
+	load.Crawled = len(w.Schedules) + len(w.Errors)
+	load.CrawledAt = w.At.UTC().Format(time.RFC3339)
+	load.Errors = w.Errors
+	c.JSON(http.StatusOK, load)
+}
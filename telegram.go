@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// botToken enables the Telegram bot; botSemester is the term used when a
+// message doesn't name one, e.g. DLU_BOT_SEMESTER=HK1-2025.
+var (
+	botToken    = getenv("BOT_TOKEN")
+	botSemester = getenv("DLU_BOT_SEMESTER")
+)
+
+const telegramAPI = "https://api.telegram.org/bot"
+
+var telegramClient = &http.Client{Timeout: 60 * time.Second}
+
+type telegramUpdate struct {
+	UpdateID int `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// botSubscriber receives the current week every Monday morning.
+type botSubscriber struct {
+	ClassID  string
+	Semester string
+	lastWeek int
+}
+
+var (
+	botMu   sync.Mutex
+	botSubs = make(map[int64]*botSubscriber)
+)
+
+const botHelp = `Gửi: /tkb <lớp> [tuần] [học kỳ], ví dụ /tkb CTK45 3 HK1-2025
+/dangky <lớp> [học kỳ] để nhận lịch tuần mỗi sáng thứ 2, /huy để hủy.`
+
+func telegramCall(method string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := telegramClient.Post(telegramAPI+botToken+"/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram %s: %s", method, result.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(result.Result, out)
+	}
+	return nil
+}
+
+func sendMessage(chatID int64, text string) {
+	err := telegramCall("sendMessage", map[string]any{"chat_id": chatID, "text": text}, nil)
+	if err != nil {
+		log.Printf("telegram: send to %d: %v", chatID, err)
+	}
+}
+
+// runTelegramBot long-polls for messages and answers them until the process
+// exits.
+func runTelegramBot() {
+	go deliverWeekly()
+
+	offset := 0
+	for {
+		var updates []telegramUpdate
+		err := telegramCall("getUpdates", map[string]any{"offset": offset, "timeout": 50}, &updates)
+		if err != nil {
+			log.Printf("telegram: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message != nil {
+				sendMessage(u.Message.Chat.ID, handleBotMessage(u.Message.Chat.ID, u.Message.Text))
+			}
+		}
+	}
+}
+
+func handleBotMessage(chatID int64, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return botHelp
+	}
+	cmd, args := strings.ToLower(fields[0]), fields[1:]
+	if i := strings.Index(cmd, "@"); i >= 0 {
+		cmd = cmd[:i]
+	}
+
+	switch cmd {
+	case "/tkb":
+		if len(args) == 0 {
+			return botHelp
+		}
+		week := ""
+		semester := botSemester
+		if len(args) > 1 {
+			week = args[1]
+		}
+		if len(args) > 2 {
+			semester = strings.Join(args[2:], " ")
+		}
+		return botSchedule(args[0], week, semester)
+	case "/dangky":
+		if len(args) == 0 {
+			return botHelp
+		}
+		semester := botSemester
+		if len(args) > 1 {
+			semester = strings.Join(args[1:], " ")
+		}
+		if _, err := resolveSemester(semester); err != nil {
+			return err.Error()
+		}
+		botMu.Lock()
+		botSubs[chatID] = &botSubscriber{ClassID: dluparser.NormalizeClassCode(args[0]), Semester: semester}
+		botMu.Unlock()
+		return "Đã đăng ký nhận lịch lớp " + dluparser.NormalizeClassCode(args[0]) + " mỗi sáng thứ 2."
+	case "/huy":
+		botMu.Lock()
+		delete(botSubs, chatID)
+		botMu.Unlock()
+		return "Đã hủy đăng ký."
+	}
+	return botHelp
+}
+
+// botSchedule fetches a week through the same pipeline as /dlu and formats
+// it as a message. An empty week means the current one.
+func botSchedule(classID, week, semester string) string {
+	q := scheduleQuery{Week: week, ClassStudentID: classID, Semester: semester}
+	if semester != "" {
+		if s, err := resolveSemester(semester); err == nil && week == "" {
+			if n, err := weekAt(s.YearStudy, time.Now()); err == nil {
+				q.Week = fmt.Sprint(n)
+			}
+		}
+	}
+	q, err := q.resolve()
+	if err != nil {
+		return err.Error()
+	}
+	s, err := loadSchedule(context.Background(), q)
+	if err != nil {
+		return "Không tải được lịch: " + err.Error()
+	}
+	return formatTelegram(s)
+}
+
+func formatTelegram(s dluparser.Schedule) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Lịch học tuần %s – lớp %s\n", s.Week, s.Class)
+	for _, d := range s.OrderedDays() {
+		if len(d.Sang)+len(d.Chieu)+len(d.Toi) == 0 {
+			continue
+		}
+		sb.WriteString("\n" + d.VietnameseName)
+		if d.Date != "" {
+			if t, err := time.Parse("2006-01-02", d.Date); err == nil {
+				sb.WriteString(t.Format(" (02/01)"))
+			}
+		}
+		sb.WriteString("\n")
+		for i, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range slot {
+				fmt.Fprintf(&sb, "  %s: %s, tiết %s", dluparser.Slots[i], sub.Name, sub.Period)
+				if sub.Room != "" {
+					sb.WriteString(", phòng " + sub.Room)
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+	if len(s.Warnings) > 0 {
+		sb.WriteString("\n⚠ " + s.Warnings[0].Message + "\n")
+	}
+	return sb.String()
+}
+
+// deliverWeekly sends each subscriber their current week once, after 6:00
+// on Monday campus time.
+func deliverWeekly() {
+	for now := range time.Tick(10 * time.Minute) {
+		local := now.In(campusTZ)
+		if local.Weekday() != time.Monday || local.Hour() < 6 {
+			continue
+		}
+
+		botMu.Lock()
+		due := make(map[int64]botSubscriber)
+		for chatID, sub := range botSubs {
+			s, err := resolveSemester(sub.Semester)
+			if err != nil {
+				continue
+			}
+			week, err := weekAt(s.YearStudy, now)
+			if err != nil || week == sub.lastWeek {
+				continue
+			}
+			sub.lastWeek = week
+			due[chatID] = *sub
+		}
+		botMu.Unlock()
+
+		for chatID, sub := range due {
+			sendMessage(chatID, botSchedule(sub.ClassID, fmt.Sprint(sub.lastWeek), sub.Semester))
+		}
+	}
+}
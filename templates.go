@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// templateNames are the portal layouts to try, in order, configured with
+// DLU_TEMPLATES. The first is fetched from DLU_UPSTREAM_URL; when a page
+// comes back with no timetable rows the next layout is fetched in its place,
+// so a change of the portal's default view doesn't take the API down.
+var templateNames = strings.Split(envString("DLU_TEMPLATES", "Mau2,Mau1,Mau3"), ",")
+
+// templateSuffixRe finds the layout in an upstream URL such as
+// ".../DrawingClassStudentSchedules_Mau2".
+var templateSuffixRe = regexp.MustCompile(`_Mau\d+$`)
+
+type scheduleTemplate struct {
+	Name   string
+	URL    string
+	parser dluparser.Parser
+}
+
+// loadTemplates resolves template names against a portal's URL. Mau2 uses
+// the given parser, built from the configured selectors; the other layouts
+// have fixed parsers. Fallbacks need a URL naming its layout to derive their
+// own from, so without one only the first template is used.
+func loadTemplates(base string, names []string, mau2 dluparser.Parser) ([]scheduleTemplate, error) {
+	var out []scheduleTemplate
+	for i, name := range names {
+		t := scheduleTemplate{URL: base}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "mau1":
+			t.Name, t.parser = "Mau1", dluparser.DayColumnsParser{}
+		case "mau2":
+			t.Name, t.parser = "Mau2", mau2
+		case "mau3":
+			t.Name, t.parser = "Mau3", dluparser.ListParser{}
+		default:
+			return nil, fmt.Errorf("unknown template %q, expected Mau1, Mau2 or Mau3", name)
+		}
+		if templateSuffixRe.MatchString(base) {
+			t.URL = templateSuffixRe.ReplaceAllString(base, "_"+t.Name)
+		} else if i > 0 {
+			continue
+		}
+		out = append(out, t)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no templates configured")
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"dlu-api/pkg/dluparser"
+)
+
+const (
+	textDayCol  = 10
+	textSlotCol = 26
+)
+
+// textWidth counts terminal columns, leaving out combining marks so
+// decomposed Vietnamese lines up with precomposed.
+func textWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.Is(unicode.Mn, r) {
+			n++
+		}
+	}
+	return n
+}
+
+// renderText draws the week as a monospace table for terminals and
+// plain-text chat bots.
+func renderText(s dluparser.Schedule) ([]byte, error) {
+	var b strings.Builder
+	title, subtitle := scheduleHeading(s)
+	b.WriteString(title + "\n")
+	if subtitle != "" {
+		b.WriteString(subtitle + "\n")
+	}
+	b.WriteString("\n")
+
+	widths := []int{textDayCol}
+	for range dluparser.Slots {
+		widths = append(widths, textSlotCol)
+	}
+	rule := "+"
+	for _, w := range widths {
+		rule += strings.Repeat("-", w+2) + "+"
+	}
+	rule += "\n"
+	row := func(cells [][]string) {
+		height := 0
+		for _, c := range cells {
+			height = max(height, len(c))
+		}
+		for i := 0; i < height; i++ {
+			b.WriteString("|")
+			for j, c := range cells {
+				l := ""
+				if i < len(c) {
+					l = c[i]
+				}
+				b.WriteString(" " + l + strings.Repeat(" ", widths[j]-textWidth(l)) + " |")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(rule)
+	}
+
+	b.WriteString(rule)
+	header := [][]string{{"Thứ"}}
+	for _, slot := range dluparser.Slots {
+		header = append(header, []string{slot})
+	}
+	row(header)
+	days := daysByIndex(s)
+	for n := 1; n <= 7; n++ {
+		day := days[n]
+		cells := [][]string{{dluparser.VietnameseDayNames[n]}}
+		if day.Date != "" {
+			cells[0] = append(cells[0], shortDate(day.Date))
+		}
+		for _, subjects := range [][]dluparser.Subject{day.Sang, day.Chieu, day.Toi} {
+			var lines []string
+			for i, sub := range subjects {
+				if i > 0 {
+					lines = append(lines, "")
+				}
+				text := sessionText(sub)
+				if sub.Period != "" {
+					text += "\nTiết: " + sub.Period
+				}
+				for _, l := range strings.Split(text, "\n") {
+					lines = append(lines, wrapLines(l, textSlotCol, func(l string) float64 { return float64(textWidth(l)) })...)
+				}
+			}
+			cells = append(cells, lines)
+		}
+		row(cells)
+	}
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+)
+
+// clock is a time of day in minutes since midnight.
+type clock int
+
+func (c clock) String() string {
+	return time.Date(0, 1, 1, int(c)/60, int(c)%60, 0, 0, time.UTC).Format("15:04")
+}
+
+func hm(h, m int) clock { return clock(h*60 + m) }
+
+type periodTime struct {
+	Start, End clock
+}
+
+// periodTable maps period numbers to their clock times.
+type periodTable map[int]periodTime
+
+// periodTimes is DLU's bell schedule: 45-minute periods, 1–5 in the
+// morning, 6–10 in the afternoon and 11–14 in the evening. Entries can be
+// overridden with DLU_PERIOD_TIMES="1=07:00-07:45,2=07:50-08:35".
+var periodTimes = loadPeriodTimes("DLU_PERIOD_TIMES", defaultPeriodTimes, getenv("DLU_PERIOD_TIMES"))
+
+// classPeriodTimes are bell schedules for faculties that keep their own,
+// keyed by class code prefix and configured as
+// DLU_CLASS_PERIOD_TIMES="CTK=1=06:45-07:30,2=07:35-08:20;QTK46=6=12:45-13:30".
+// Each table starts from periodTimes and overrides the periods it lists.
+var classPeriodTimes = loadClassPeriodTimes(getenv("DLU_CLASS_PERIOD_TIMES"))
+
+type classPeriodTable struct {
+	prefix string
+	table  periodTable
+}
+
+var defaultPeriodTimes = periodTable{
+	1:  {hm(7, 0), hm(7, 45)},
+	2:  {hm(7, 50), hm(8, 35)},
+	3:  {hm(8, 40), hm(9, 25)},
+	4:  {hm(9, 35), hm(10, 20)},
+	5:  {hm(10, 25), hm(11, 10)},
+	6:  {hm(13, 0), hm(13, 45)},
+	7:  {hm(13, 50), hm(14, 35)},
+	8:  {hm(14, 40), hm(15, 25)},
+	9:  {hm(15, 35), hm(16, 20)},
+	10: {hm(16, 25), hm(17, 10)},
+	11: {hm(17, 30), hm(18, 15)},
+	12: {hm(18, 20), hm(19, 5)},
+	13: {hm(19, 10), hm(19, 55)},
+	14: {hm(20, 0), hm(20, 45)},
+}
+
+func loadPeriodTimes(setting string, defaults periodTable, raw string) periodTable {
+	table := make(periodTable, len(defaults))
+	for p, t := range defaults {
+		table[p] = t
+	}
+	for _, entry := range strings.Split(raw, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		num, span, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		from, to, ok2 := strings.Cut(span, "-")
+		p, err := strconv.Atoi(strings.TrimSpace(num))
+		start, err1 := parseClock(from)
+		end, err2 := parseClock(to)
+		if !ok || !ok2 || err != nil || p < 1 || p > dluparser.MaxPeriod || err1 != nil || err2 != nil || end <= start {
+			configError("%s entry %q: expected PERIOD=HH:MM-HH:MM", setting, entry)
+			continue
+		}
+		table[p] = periodTime{start, end}
+	}
+	return table
+}
+
+// loadClassPeriodTimes reads DLU_CLASS_PERIOD_TIMES, longest prefix first so
+// that periodTableFor picks the most specific table.
+func loadClassPeriodTimes(raw string) []classPeriodTable {
+	var tables []classPeriodTable
+	for _, entry := range strings.Split(raw, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		prefix, periods, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		prefix = dluparser.NormalizeClassCode(prefix)
+		if !ok || prefix == "" {
+			configError("DLU_CLASS_PERIOD_TIMES entry %q: expected CLASS_PREFIX=PERIOD=HH:MM-HH:MM,...", entry)
+			continue
+		}
+		tables = append(tables, classPeriodTable{prefix, loadPeriodTimes("DLU_CLASS_PERIOD_TIMES "+prefix, periodTimes, periods)})
+	}
+	sort.SliceStable(tables, func(i, j int) bool { return len(tables[i].prefix) > len(tables[j].prefix) })
+	return tables
+}
+
+// periodTableFor is the bell schedule for class: the table of the longest
+// DLU_CLASS_PERIOD_TIMES prefix it starts with, else periodTimes.
+func periodTableFor(class string) periodTable {
+	class = dluparser.NormalizeClassCode(class)
+	for _, t := range classPeriodTimes {
+		if strings.HasPrefix(class, t.prefix) {
+			return t.table
+		}
+	}
+	return periodTimes
+}
+
+func parseClock(s string) (clock, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return hm(t.Hour(), t.Minute()), nil
+}
+
+// stampTimes fills StartTime/EndTime on every subject whose first and last
+// periods are in the class's bell schedule.
+func stampTimes(s *dluparser.Schedule) {
+	table := periodTableFor(s.Class)
+	for name, d := range s.Days {
+		for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for i := range slot {
+				sub := &slot[i]
+				if start, end, ok := table.span(sub.PeriodStart, sub.PeriodEnd); ok {
+					sub.StartTime, sub.EndTime = start.String(), end.String()
+				}
+			}
+		}
+		s.Days[name] = d
+	}
+}
+
+// periodRuns splits sorted periods into contiguous runs, so "1-3,7" becomes
+// [1 3] and [7 7].
+func periodRuns(periods []int) [][2]int {
+	var runs [][2]int
+	for _, p := range periods {
+		if n := len(runs); n > 0 && runs[n-1][1] == p-1 {
+			runs[n-1][1] = p
+			continue
+		}
+		runs = append(runs, [2]int{p, p})
+	}
+	return runs
+}
+
+// periodSession places a period in a session by when it starts, so periods
+// added through DLU_PERIOD_TIMES land in the right one. A period the bell
+// schedule doesn't list takes the session of the nearest listed period
+// before it, or after it when there is none before.
+func periodSession(p int) int {
+	if t, ok := periodTimes[p]; ok {
+		return dluparser.SlotAt(int(t.Start))
+	}
+	below, above := 0, 0
+	for q := range periodTimes {
+		switch {
+		case q < p && q > below:
+			below = q
+		case q > p && (above == 0 || q < above):
+			above = q
+		}
+	}
+	if below == 0 {
+		below = above
+	}
+	if t, ok := periodTimes[below]; ok {
+		return dluparser.SlotAt(int(t.Start))
+	}
+	return 0
+}
+
+// sessionSpan returns the clock span covered by periods first..last in the
+// default bell schedule, or false if either end is missing from it.
+func sessionSpan(first, last int) (start, end clock, ok bool) {
+	return periodTimes.span(first, last)
+}
+
+// span is sessionSpan for table t.
+func (t periodTable) span(first, last int) (start, end clock, ok bool) {
+	a, okA := t[first]
+	b, okB := t[last]
+	return a.Start, b.End, okA && okB
+}
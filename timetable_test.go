@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"dlu-api/pkg/dluparser"
+)
+
+func TestClassPeriodTimes(t *testing.T) {
+	prev := classPeriodTimes
+	t.Cleanup(func() { classPeriodTimes = prev })
+	classPeriodTimes = loadClassPeriodTimes("CTK=1=06:45-07:30,2=07:35-08:20; QTK46=6=12:45-13:30;qtk=6=12:30-13:15")
+
+	tests := []struct {
+		class      string
+		period     int
+		start, end string
+	}{
+		{"CTK45", 1, "06:45", "07:30"},
+		// Periods a table doesn't list keep their default times.
+		{"CTK45", 3, "08:40", "09:25"},
+		{"ctk47a", 2, "07:35", "08:20"},
+		{"QTK46A", 6, "12:45", "13:30"},
+		{"QTK45", 6, "12:30", "13:15"},
+		{"KTK45", 1, "07:00", "07:45"},
+		{"", 1, "07:00", "07:45"},
+	}
+	for _, tt := range tests {
+		s := dluparser.Schedule{Class: tt.class, Days: map[string]dluparser.DaySchedule{
+			"Thứ 2": {Sang: []dluparser.Subject{{Name: "x", PeriodStart: tt.period, PeriodEnd: tt.period}}},
+		}}
+		stampTimes(&s)
+		sub := s.Days["Thứ 2"].Sang[0]
+		if sub.StartTime != tt.start || sub.EndTime != tt.end {
+			t.Errorf("%s period %d: %s-%s, want %s-%s", tt.class, tt.period, sub.StartTime, sub.EndTime, tt.start, tt.end)
+		}
+	}
+	if _, _, ok := sessionSpan(1, 1); !ok || periodTimes[1].Start.String() != "07:00" {
+		t.Errorf("class tables changed the default schedule")
+	}
+}
+
+func TestPeriodSessionOutsideTable(t *testing.T) {
+	prev := periodTimes
+	t.Cleanup(func() { periodTimes = prev })
+	periodTimes = loadPeriodTimes("DLU_PERIOD_TIMES", defaultPeriodTimes, "")
+	delete(periodTimes, 1)
+	delete(periodTimes, 8)
+
+	tests := []struct {
+		period, want int
+	}{
+		{0, 0},
+		{1, 0},
+		{5, 0},
+		{8, 1},
+		{11, 2},
+		{14, 2},
+		{16, 2},
+	}
+	for _, tt := range tests {
+		if got := periodSession(tt.period); got != tt.want {
+			t.Errorf("periodSession(%d) = %d, want %d", tt.period, got, tt.want)
+		}
+	}
+
+	// A subject running past the table's last period widens Tối, not Sáng,
+	// so the session headers don't overlap.
+	got := xlsxSessionCols(16)
+	want := [3][2]int{{2, 6}, {7, 11}, {12, 17}}
+	if got != want {
+		t.Errorf("xlsxSessionCols(16) = %v, want %v", got, want)
+	}
+}
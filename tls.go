@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// upstreamTLSConfig verifies the portal's certificate normally by default.
+// The portal doesn't always serve a chain that validates against system
+// roots, so operators can instead
+//
+//   - add its chain with DLU_UPSTREAM_CA_FILE (a PEM bundle),
+//   - pin the leaf certificate with DLU_UPSTREAM_PIN_SHA256 (hex SHA-256 of
+//     the DER certificate, comma-separated to allow rotation), or
+//   - opt out with DLU_UPSTREAM_INSECURE=true, which still checks that the
+//     leaf names the dialed host.
+var (
+	upstreamCAFile   = getenv("DLU_UPSTREAM_CA_FILE")
+	upstreamPins     = loadPins(getenv("DLU_UPSTREAM_PIN_SHA256"))
+	upstreamInsecure = envBool("DLU_UPSTREAM_INSECURE", false)
+)
+
+func upstreamTLSConfig() *tls.Config {
+	cfg := &tls.Config{}
+
+	if path := upstreamCAFile; path != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			configError("DLU_UPSTREAM_CA_FILE: %v", err)
+		} else if !pool.AppendCertsFromPEM(pem) {
+			configError("DLU_UPSTREAM_CA_FILE %s: no PEM certificates found", path)
+		}
+		cfg.RootCAs = pool
+	}
+
+	pins := upstreamPins
+	if len(pins) == 0 && !upstreamInsecure {
+		return cfg
+	}
+
+	// A pin stands in for chain validation unless a CA bundle was given, in
+	// which case both must pass.
+	cfg.InsecureSkipVerify = cfg.RootCAs == nil
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("upstream presented no certificate")
+		}
+		leaf := cs.PeerCertificates[0]
+		if err := leaf.VerifyHostname(cs.ServerName); err != nil {
+			return err
+		}
+		if len(pins) == 0 {
+			return nil
+		}
+		sum := sha256.Sum256(leaf.Raw)
+		if !pins[hex.EncodeToString(sum[:])] {
+			return fmt.Errorf("upstream certificate %x does not match DLU_UPSTREAM_PIN_SHA256", sum)
+		}
+		return nil
+	}
+	return cfg
+}
+
+func loadPins(raw string) map[string]bool {
+	pins := make(map[string]bool)
+	for _, pin := range strings.Split(raw, ",") {
+		pin = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(pin), ":", ""))
+		if pin == "" {
+			continue
+		}
+		if b, err := hex.DecodeString(pin); err != nil || len(b) != sha256.Size {
+			configError("DLU_UPSTREAM_PIN_SHA256 entry %q: expected a hex SHA-256 fingerprint", pin)
+			continue
+		}
+		pins[pin] = true
+	}
+	return pins
+}
+
+func tlsSummary() string {
+	var parts []string
+	if upstreamCAFile != "" {
+		parts = append(parts, "ca_file")
+	}
+	if len(upstreamPins) > 0 {
+		parts = append(parts, "pinned")
+	}
+	if upstreamInsecure {
+		parts = append(parts, "insecure")
+	}
+	if len(parts) == 0 {
+		return "verify"
+	}
+	return strings.Join(parts, "+")
+}
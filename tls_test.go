@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpstreamTLS(t *testing.T) {
+	// httptest's certificate is self-signed and names example.com but not
+	// localhost. Both are dialed to the test server.
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	dial := func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	matching := "https://example.com/"
+	mismatched := "https://localhost/"
+
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	pin := hex.EncodeToString(sum[:])
+
+	prevInsecure, prevPins := upstreamInsecure, upstreamPins
+	t.Cleanup(func() { upstreamInsecure, upstreamPins = prevInsecure, prevPins })
+
+	tests := []struct {
+		name     string
+		insecure bool
+		pins     map[string]bool
+		url      string
+		ok       bool
+	}{
+		{"verify rejects an untrusted chain", false, nil, matching, false},
+		{"insecure accepts a matching hostname", true, nil, matching, true},
+		{"insecure rejects a mismatched hostname", true, nil, mismatched, false},
+		{"pin accepts the pinned certificate", false, map[string]bool{pin: true}, matching, true},
+		{"pin rejects another certificate", false, map[string]bool{hex.EncodeToString(make([]byte, 32)): true}, matching, false},
+		{"pin still checks the hostname", false, map[string]bool{pin: true}, mismatched, false},
+	}
+	for _, tt := range tests {
+		upstreamInsecure, upstreamPins = tt.insecure, tt.pins
+		client := &http.Client{Transport: &http.Transport{DialContext: dial, TLSClientConfig: upstreamTLSConfig()}}
+		resp, err := client.Get(tt.url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	h := newTestServer(t, servePage(t, "mau2.html"))
+
+	tests := []struct {
+		cache string
+		spans []string
+	}{
+		{"miss", []string{"cache.lookup", "upstream.attempt", "upstream.fetch", "parse", "GET /dlu"}},
+		{"hit", []string{"cache.lookup", "GET /dlu"}},
+	}
+	for _, tt := range tests {
+		before := len(rec.Ended())
+		if res := get(t, h, "/dlu?"+weekParams+"&ClassStudentID=CTK45"); res.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", res.Code, res.Body)
+		}
+		spans := rec.Ended()[before:]
+		var names []string
+		for _, s := range spans {
+			names = append(names, s.Name())
+		}
+		if len(names) != len(tt.spans) {
+			t.Fatalf("%s: spans %v, want %v", tt.cache, names, tt.spans)
+		}
+		root := spans[len(spans)-1]
+		for i, s := range spans {
+			if s.Name() != tt.spans[i] {
+				t.Errorf("%s: span %d is %s, want %s", tt.cache, i, s.Name(), tt.spans[i])
+			}
+			if s.SpanContext().TraceID() != root.SpanContext().TraceID() {
+				t.Errorf("%s: %s is in another trace", tt.cache, s.Name())
+			}
+			if s.Name() == "cache.lookup" && !hasAttr(s, "dlu.cache", tt.cache) {
+				t.Errorf("%s: cache.lookup attributes %v", tt.cache, s.Attributes())
+			}
+		}
+		if !hasAttr(root, "http.route", "/dlu") || !hasAttr(root, "http.response.status_code", "200") {
+			t.Errorf("%s: server span attributes %v", tt.cache, root.Attributes())
+		}
+	}
+}
+
+func hasAttr(s sdktrace.ReadOnlySpan, key, value string) bool {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key && kv.Value.Emit() == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fontPath names a TrueType font for the PDF and PNG renderers to draw text
+// with instead of the bundled DejaVu Sans, e.g. for a different typeface. It
+// needs Vietnamese coverage.
+var (
+	fontPath   = getenv("DLU_FONT")
+	renderFont = defaultFont()
+)
+
+// dejaVuSans is DejaVu Sans, under the Bitstream Vera license in
+// fonts/LICENSE. It covers every Vietnamese letter.
+//
+//go:embed fonts/DejaVuSans.ttf
+var dejaVuSans []byte
+
+func defaultFont() *ttfFont {
+	f, err := parseTTF(dejaVuSans)
+	if err != nil {
+		panic("bundled font: " + err.Error())
+	}
+	f.name = "DejaVuSans"
+	return f
+}
+
+// ttfFont is what the renderers need from a TrueType font: glyph lookup,
+// advance widths, outlines and the metrics for a PDF font descriptor. PDFs
+// embed the file itself as is.
+type ttfFont struct {
+	name       string
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	advances   []int
+	glyphs     map[rune]uint16
+
+	// glyf and loca hold the outlines, for rasterizing.
+	glyf []byte
+	loca []int
+}
+
+func loadTTF(path string) (*ttfFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseTTF(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f.name = strings.Map(func(r rune) rune {
+		if r > ' ' && r < 0x7F && !strings.ContainsRune("()<>[]{}/%#", r) {
+			return r
+		}
+		return -1
+	}, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if f.name == "" {
+		f.name = "Font"
+	}
+	return f, nil
+}
+
+var errTTF = errors.New("not a TrueType font")
+
+func parseTTF(data []byte) (*ttfFont, error) {
+	if len(data) < 12 {
+		return nil, errTTF
+	}
+	if v := binary.BigEndian.Uint32(data); v != 0x00010000 && v != 0x74727565 { // "true"
+		return nil, fmt.Errorf("%w (OpenType CFF and collections are not supported)", errTTF)
+	}
+	tables := make(map[string][]byte)
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < n; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errTTF
+		}
+		off := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if off < 0 || length < 0 || off+length > len(data) {
+			return nil, errTTF
+		}
+		tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	head, hhea, maxp, hmtx, cmap := tables["head"], tables["hhea"], tables["maxp"], tables["hmtx"], tables["cmap"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 || hmtx == nil || cmap == nil {
+		return nil, fmt.Errorf("%w: missing required tables", errTTF)
+	}
+
+	i16 := func(b []byte, off int) int { return int(int16(binary.BigEndian.Uint16(b[off:]))) }
+	u16 := func(b []byte, off int) int { return int(binary.BigEndian.Uint16(b[off:])) }
+	f := &ttfFont{
+		data:       data,
+		unitsPerEm: u16(head, 18),
+		bbox:       [4]int{i16(head, 36), i16(head, 38), i16(head, 40), i16(head, 42)},
+		ascent:     i16(hhea, 4),
+		descent:    i16(hhea, 6),
+	}
+	if f.unitsPerEm == 0 {
+		return nil, errTTF
+	}
+
+	numGlyphs, numMetrics := u16(maxp, 4), u16(hhea, 34)
+	if numMetrics == 0 || len(hmtx) < 4*numMetrics {
+		return nil, fmt.Errorf("%w: bad hmtx table", errTTF)
+	}
+	f.advances = make([]int, numGlyphs)
+	for g := range f.advances {
+		f.advances[g] = u16(hmtx, 4*min(g, numMetrics-1))
+	}
+
+	glyphs, err := parseCmap(cmap)
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs = glyphs
+
+	if glyf, loca := tables["glyf"], tables["loca"]; glyf != nil && loca != nil {
+		long := i16(head, 50) == 1
+		for g := 0; g <= numGlyphs; g++ {
+			switch {
+			case long && 4*g+4 <= len(loca):
+				f.loca = append(f.loca, int(binary.BigEndian.Uint32(loca[4*g:])))
+			case !long && 2*g+2 <= len(loca):
+				f.loca = append(f.loca, 2*u16(loca, 2*g))
+			}
+		}
+		f.glyf = glyf
+	}
+	return f, nil
+}
+
+// parseCmap reads the Unicode mapping, preferring the full-range format 12
+// subtable over the BMP-only format 4.
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errTTF
+	}
+	var fmt4, fmt12 []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(cmap[2:])); i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform, encoding := binary.BigEndian.Uint16(cmap[rec:]), binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+4 > len(cmap) || (platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10))) {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[off:]) {
+		case 4:
+			fmt4 = cmap[off:]
+		case 12:
+			fmt12 = cmap[off:]
+		}
+	}
+
+	glyphs := make(map[rune]uint16)
+	switch {
+	case len(fmt12) >= 16:
+		groups := int(binary.BigEndian.Uint32(fmt12[12:]))
+		for i := 0; i < groups && 16+12*i+12 <= len(fmt12); i++ {
+			g := fmt12[16+12*i:]
+			start, end, gid := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:]), binary.BigEndian.Uint32(g[8:])
+			for r := start; r <= end && r <= 0x10FFFF; r++ {
+				glyphs[rune(r)] = uint16(gid + r - start)
+			}
+		}
+	case len(fmt4) >= 14:
+		segs := int(binary.BigEndian.Uint16(fmt4[6:])) / 2
+		ends, starts, deltas, ranges := 14, 16+2*segs, 16+4*segs, 16+6*segs
+		if ranges+2*segs > len(fmt4) {
+			return nil, fmt.Errorf("%w: bad cmap table", errTTF)
+		}
+		for s := 0; s < segs; s++ {
+			end := int(binary.BigEndian.Uint16(fmt4[ends+2*s:]))
+			start := int(binary.BigEndian.Uint16(fmt4[starts+2*s:]))
+			delta := int(binary.BigEndian.Uint16(fmt4[deltas+2*s:]))
+			rangeOff := int(binary.BigEndian.Uint16(fmt4[ranges+2*s:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				gid := (c + delta) & 0xFFFF
+				if rangeOff != 0 {
+					at := ranges + 2*s + rangeOff + 2*(c-start)
+					if at+2 > len(fmt4) {
+						continue
+					}
+					if gid = int(binary.BigEndian.Uint16(fmt4[at:])); gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				if gid != 0 {
+					glyphs[rune(c)] = uint16(gid)
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: no Unicode cmap", errTTF)
+	}
+	return glyphs, nil
+}
+
+func (f *ttfFont) glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// width is the advance of s at size points.
+func (f *ttfFont) width(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		if g := int(f.glyph(r)); g < len(f.advances) {
+			total += f.advances[g]
+		}
+	}
+	return float64(total) * size / float64(f.unitsPerEm)
+}
+
+// wrapText breaks s into lines no wider than width at size.
+func wrapText(f *ttfFont, s string, size, width float64) []string {
+	return wrapLines(s, width, func(l string) float64 { return f.width(l, size) })
+}
+
+type ttfPoint struct {
+	x, y float64
+	on   bool
+}
+
+// outline returns the glyph's contours in font units, with composite glyphs
+// expanded.
+func (f *ttfFont) outline(gid uint16) [][]ttfPoint {
+	return f.outlineDepth(int(gid), 0)
+}
+
+func (f *ttfFont) outlineDepth(g, depth int) [][]ttfPoint {
+	if depth > 8 || g+1 >= len(f.loca) {
+		return nil
+	}
+	start, end := f.loca[g], f.loca[g+1]
+	if start >= end || end > len(f.glyf) || end-start < 10 {
+		return nil
+	}
+	b := f.glyf[start:end]
+	u16 := func(off int) int {
+		if off+2 > len(b) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(b[off:]))
+	}
+	i16 := func(off int) int { return int(int16(u16(off))) }
+
+	contours := i16(0)
+	if contours < 0 {
+		return f.composite(b, depth)
+	}
+
+	ends := make([]int, contours)
+	for i := range ends {
+		ends[i] = u16(10 + 2*i)
+	}
+	if contours == 0 {
+		return nil
+	}
+	points := ends[contours-1] + 1
+	pos := 10 + 2*contours
+	pos += 2 + u16(pos)
+
+	flags := make([]byte, 0, points)
+	for len(flags) < points && pos < len(b) {
+		fl := b[pos]
+		pos++
+		flags = append(flags, fl)
+		if fl&0x08 != 0 && pos < len(b) {
+			for n := int(b[pos]); n > 0 && len(flags) < points; n-- {
+				flags = append(flags, fl)
+			}
+			pos++
+		}
+	}
+	if len(flags) < points {
+		return nil
+	}
+	coords := func(short, same byte) []float64 {
+		out := make([]float64, points)
+		v := 0
+		for i, fl := range flags {
+			switch {
+			case fl&short != 0:
+				if pos >= len(b) {
+					return out
+				}
+				d := int(b[pos])
+				pos++
+				if fl&same == 0 {
+					d = -d
+				}
+				v += d
+			case fl&same == 0:
+				v += i16(pos)
+				pos += 2
+			}
+			out[i] = float64(v)
+		}
+		return out
+	}
+	xs := coords(0x02, 0x10)
+	ys := coords(0x04, 0x20)
+
+	var out [][]ttfPoint
+	first := 0
+	for _, last := range ends {
+		if last >= points || last < first {
+			break
+		}
+		var c []ttfPoint
+		for i := first; i <= last; i++ {
+			c = append(c, ttfPoint{xs[i], ys[i], flags[i]&0x01 != 0})
+		}
+		out = append(out, c)
+		first = last + 1
+	}
+	return out
+}
+
+// composite assembles a glyph made of transformed references to others,
+// which is how many fonts build accented Vietnamese letters.
+func (f *ttfFont) composite(b []byte, depth int) [][]ttfPoint {
+	var out [][]ttfPoint
+	pos := 10
+	for pos+4 <= len(b) {
+		flags := binary.BigEndian.Uint16(b[pos:])
+		gid := int(binary.BigEndian.Uint16(b[pos+2:]))
+		pos += 4
+
+		var dx, dy float64
+		if flags&0x0001 != 0 {
+			if pos+4 > len(b) {
+				break
+			}
+			dx, dy = float64(int16(binary.BigEndian.Uint16(b[pos:]))), float64(int16(binary.BigEndian.Uint16(b[pos+2:])))
+			pos += 4
+		} else {
+			if pos+2 > len(b) {
+				break
+			}
+			dx, dy = float64(int8(b[pos])), float64(int8(b[pos+1]))
+			pos += 2
+		}
+		if flags&0x0002 == 0 {
+			// Point-matched placement; rare enough to ignore.
+			dx, dy = 0, 0
+		}
+
+		f2dot14 := func() float64 {
+			if pos+2 > len(b) {
+				return 1
+			}
+			v := float64(int16(binary.BigEndian.Uint16(b[pos:]))) / 16384
+			pos += 2
+			return v
+		}
+		a, bb, c, d := 1.0, 0.0, 0.0, 1.0
+		switch {
+		case flags&0x0008 != 0:
+			a = f2dot14()
+			d = a
+		case flags&0x0040 != 0:
+			a, d = f2dot14(), f2dot14()
+		case flags&0x0080 != 0:
+			a, bb, c, d = f2dot14(), f2dot14(), f2dot14(), f2dot14()
+		}
+
+		for _, contour := range f.outlineDepth(gid, depth+1) {
+			moved := make([]ttfPoint, len(contour))
+			for i, p := range contour {
+				moved[i] = ttfPoint{a*p.x + c*p.y + dx, bb*p.x + d*p.y + dy, p.on}
+			}
+			out = append(out, moved)
+		}
+		if flags&0x0020 == 0 {
+			break
+		}
+	}
+	return out
+}
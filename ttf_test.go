@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name, or rewrites it under -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := "testdata/" + name
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs from the golden file; rerun with -update if the change is intended", name)
+	}
+}
+
+func TestBundledFont(t *testing.T) {
+	f := renderFont
+	if f.name != "DejaVuSans" || f.unitsPerEm != 2048 {
+		t.Fatalf("bundled font is %q with %d units per em", f.name, f.unitsPerEm)
+	}
+	for _, r := range "aạảãàáâầấậẩẫăằắặẳẵeẹẻẽèéêềếệểễiịỉĩìíoọỏõòóôồốộổỗơờớợởỡuụủũùúưừứựửữyỵỷỹỳýđ" {
+		for _, c := range []rune{r, []rune(strings.ToUpper(string(r)))[0]} {
+			if f.glyph(c) == 0 {
+				t.Errorf("no glyph for %c", c)
+			}
+		}
+	}
+	if w := f.width("Thứ 2", 10); w < 20 || w > 30 {
+		t.Errorf("width(Thứ 2) at 10pt = %.2f", w)
+	}
+}
+
+func TestGlyphOutline(t *testing.T) {
+	// A and o are simple glyphs with lines and curves; Đ and ệ are
+	// composites of a base letter and marks.
+	var b strings.Builder
+	for _, r := range "AoĐệ" {
+		fmt.Fprintf(&b, "%c\n", r)
+		for i, c := range renderFont.outline(renderFont.glyph(r)) {
+			fmt.Fprintf(&b, "  contour %d:", i)
+			for _, p := range c {
+				on := "~"
+				if p.on {
+					on = ""
+				}
+				fmt.Fprintf(&b, " %s%g,%g", on, p.x, p.y)
+			}
+			b.WriteString("\n")
+		}
+	}
+	golden(t, "outlines.golden", []byte(b.String()))
+}
+
+func TestOutlineBadGlyph(t *testing.T) {
+	f := renderFont
+	for _, gid := range []uint16{0xFFFF, uint16(len(f.loca) - 1)} {
+		if c := f.outline(gid); c != nil {
+			t.Errorf("outline(%d) = %d contours, want none", gid, len(c))
+		}
+	}
+	if c := f.outline(f.glyph(' ')); c != nil {
+		t.Errorf("space has %d contours", len(c))
+	}
+}
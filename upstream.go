@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var upstreamURL = envString("DLU_UPSTREAM_URL", "https://qlgd.dlu.edu.vn/public/DrawingClassStudentSchedules_Mau2")
+
+// maxUpstreamBytes bounds how much of an upstream page is read; real
+// timetable pages are a few tens of kilobytes.
+const maxUpstreamBytes = 4 << 20
+
+var httpClient = &http.Client{Transport: newTransport()}
+
+// newTransport builds the shared upstream transport. Every request goes to
+// the same host, so the idle pool per host is sized like the overall pool
+// rather than left at net/http's default of 2.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   envDuration("DLU_UPSTREAM_CONNECT_TIMEOUT", 5*time.Second),
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: envDuration("DLU_UPSTREAM_TLS_TIMEOUT", 5*time.Second),
+		TLSClientConfig:     upstreamTLSConfig(),
+		MaxIdleConns:        envInt("DLU_UPSTREAM_MAX_IDLE_CONNS", 16),
+		MaxIdleConnsPerHost: envInt("DLU_UPSTREAM_MAX_IDLE_CONNS_PER_HOST", 16),
+		IdleConnTimeout:     envDuration("DLU_UPSTREAM_IDLE_CONN_TIMEOUT", 90*time.Second),
+		// A custom TLS config turns off HTTP/2 unless explicitly requested.
+		ForceAttemptHTTP2: envBool("DLU_UPSTREAM_HTTP2", true),
+	}
+}
+
+func scheduleURL(q scheduleQuery) string {
+	return upstreamURL + "?" + scheduleParams(q)
+}
+
+func scheduleParams(q scheduleQuery) string {
+	v := url.Values{}
+	v.Set("YearStudy", q.YearStudy)
+	v.Set("TermID", q.TermID)
+	v.Set("Week", q.Week)
+	v.Set("ClassStudentID", q.ClassStudentID)
+	return v.Encode()
+}
+
+// errUpstreamTimeout marks fetches that ran out of time, which handlers
+// answer with 504 rather than 500.
+var errUpstreamTimeout = errors.New("upstream timed out")
+
+var upstreamTimeout = envDuration("DLU_UPSTREAM_TIMEOUT", 20*time.Second)
+
+// upstreamError answers a failed upstream fetch: 504 for timeouts, 503 with
+// Retry-After while the circuit breaker is open, 429 when the portal is
+// throttling us, 500 otherwise. Fetches that got as far as the portal also
+// report how many attempts were made and the last status it returned.
+func upstreamError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	var open *openError
+	var se *statusError
+	switch {
+	case errors.As(err, &open):
+		c.Header("Retry-After", strconv.Itoa(int(open.retryAfter.Seconds()+0.999)))
+		status = http.StatusServiceUnavailable
+	case errors.As(err, &se) && se.Code == http.StatusTooManyRequests:
+		// Pass the portal's throttling on rather than retrying past it.
+		if se.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(se.RetryAfter.Seconds()+0.999)))
+		}
+		status = http.StatusTooManyRequests
+	case errors.Is(err, errUpstreamTimeout):
+		status = http.StatusGatewayTimeout
+	}
+	body := gin.H{"error": err.Error()}
+	var re *retryError
+	if errors.As(err, &re) {
+		body["attempts"] = re.Attempts
+		if re.LastStatus != 0 {
+			body["upstream_status"] = re.LastStatus
+		}
+	}
+	c.JSON(status, body)
+}
+
+// fetchHTML fetches a schedule page, also reporting how many attempts it
+// took.
+func fetchHTML(ctx context.Context, q scheduleQuery) ([]byte, int, error) {
+	return fetchAttempts(ctx, scheduleURL(q))
+}
+
+func fetchURL(ctx context.Context, u string) ([]byte, error) {
+	body, _, err := fetchAttempts(ctx, u)
+	return body, err
+}
+
+// fetchAttempts GETs an upstream page within DLU_UPSTREAM_TIMEOUT, retrying
+// transient failures and giving up early if ctx (usually the client's
+// request) is cancelled.
+func fetchAttempts(ctx context.Context, u string) ([]byte, int, error) {
+	return fetchThrough(ctx, upstreamBreaker, u)
+}
+
+// fetchThrough is fetchAttempts behind a given provider's breaker. A nil b
+// fetches without consulting or counting against any breaker.
+func fetchThrough(ctx context.Context, b *breaker, u string) ([]byte, int, error) {
+	if b != nil {
+		if err := b.allow(); err != nil {
+			return nil, 0, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, upstreamTimeout)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "upstream.fetch", trace.WithAttributes(semconv.URLFull(u)))
+
+	start := time.Now()
+	body, attempts, err := withRetry(ctx, func() ([]byte, error) { return doFetch(ctx, u) })
+	recordUpstream(ctx, time.Since(start))
+	span.SetAttributes(attribute.Int("dlu.attempts", attempts))
+	if err != nil {
+		re := &retryError{Attempts: attempts, Err: err}
+		var se *statusError
+		if errors.As(err, &se) {
+			re.LastStatus = se.Code
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			re.Err = fmt.Errorf("%w after %s", errUpstreamTimeout, upstreamTimeout)
+		}
+		err = re
+	}
+	if b != nil {
+		b.record(err)
+	}
+	endSpan(span, err)
+	return body, attempts, err
+}
+
+// doFetch makes one attempt at an upstream page, as an upstream.attempt
+// span that includes the wait for the upstream limiter.
+func doFetch(ctx context.Context, u string) (body []byte, err error) {
+	ctx, span := tracer.Start(ctx, "upstream.attempt", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { endSpan(span, err) }()
+
+	release, err := upstreamLimiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &statusError{Code: resp.StatusCode, Status: resp.Status, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	}
+
+	reader, err := decodedBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = io.ReadAll(io.LimitReader(reader, maxUpstreamBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxUpstreamBytes {
+		return nil, fmt.Errorf("upstream response exceeds %d bytes", maxUpstreamBytes)
+	}
+	return body, nil
+}
+
+// decodedBody undoes gzip/deflate content encoding that net/http left in
+// place. The transport only decompresses transparently when it negotiated the
+// encoding itself, so a server that compresses unasked (or a request that set
+// its own Accept-Encoding) would otherwise hand us compressed bytes.
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	if resp.Uncompressed {
+		return resp.Body, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		return zlib.NewReader(resp.Body)
+	default:
+		return nil, fmt.Errorf("unsupported upstream content encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+}
+
+// fetchSchedule fetches and parses a week from q's provider, bypassing the
+// cache.
+func fetchSchedule(ctx context.Context, q scheduleQuery) (dluparser.Schedule, error) {
+	name := q.Provider
+	if name == "" {
+		name = defaultProvider
+	}
+	p, ok := providers[name]
+	if !ok {
+		return dluparser.Schedule{}, fmt.Errorf("unknown provider %q", q.Provider)
+	}
+	schedule, err := p.FetchSchedule(ctx, q)
+	if err != nil {
+		return schedule, err
+	}
+	schedule.Meta.Provider = q.Provider
+	schedule.Meta.FetchedAt = time.Now().UTC().Format(time.RFC3339)
+	checkClass(&schedule, q.ClassStudentID)
+	// History is kept for DLU classes only.
+	if q.Provider == "" {
+		recordSnapshot(q, schedule)
+	}
+	return schedule, nil
+}
+
+// parseSchedulePage parses a timetable page and stamps it with the query's
+// codes; it is shared by the class and teacher views.
+func parseSchedulePage(ctx context.Context, body []byte, q scheduleQuery) (dluparser.Schedule, error) {
+	return parsePage(ctx, htmlParser, body, q)
+}
+
+func parsePage(ctx context.Context, p dluparser.Parser, body []byte, q scheduleQuery) (dluparser.Schedule, error) {
+	_, span := tracer.Start(ctx, "parse", trace.WithAttributes(attribute.Int("dlu.page_bytes", len(body))))
+	schedule, err := p.Parse(bytes.NewReader(body))
+	endSpan(span, err)
+	if err != nil {
+		return dluparser.Schedule{}, err
+	}
+	schedule.Meta = &dluparser.Meta{
+		YearStudy: q.YearStudy,
+		TermID:    q.TermID,
+		Semester:  q.Semester,
+		Checksum:  dluparser.Checksum(schedule),
+	}
+	if week, err := strconv.Atoi(q.Week); err == nil {
+		schedule.WeekStart, _ = weekStart(q.YearStudy, week)
+	}
+	stampTimes(&schedule)
+	return schedule, nil
+}
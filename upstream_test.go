@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"dlu-api/pkg/dluparser"
+)
+
+func TestCompressedUpstream(t *testing.T) {
+	page, err := os.ReadFile("pkg/dluparser/testdata/mau2.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compress := func(w io.WriteCloser, buf *bytes.Buffer) []byte {
+		w.Write(page)
+		w.Close()
+		return buf.Bytes()
+	}
+	var gz, zl bytes.Buffer
+	gzipped := compress(gzip.NewWriter(&gz), &gz)
+	deflated := compress(zlib.NewWriter(&zl), &zl)
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		// negotiated only compresses when the client asked for gzip.
+		negotiated bool
+		want       int
+	}{
+		{"plain", "", page, false, http.StatusOK},
+		{"gzip unasked", "gzip", gzipped, false, http.StatusOK},
+		{"gzip negotiated", "gzip", gzipped, true, http.StatusOK},
+		{"x-gzip", "x-gzip", gzipped, false, http.StatusOK},
+		{"deflate", "deflate", deflated, false, http.StatusOK},
+		{"unsupported", "br", page, false, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		h := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if tt.negotiated && !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				w.Write(page)
+				return
+			}
+			if tt.encoding != "" {
+				w.Header().Set("Content-Encoding", tt.encoding)
+			}
+			w.Write(tt.body)
+		})
+		rec := get(t, h, "/dlu?"+weekParams+"&ClassStudentID=CTK45")
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var s dluparser.Schedule
+		if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := s.Days["Thứ 2"].Sang; len(got) != 1 || got[0].Name != "Lập trình Go" {
+			t.Errorf("%s: Thứ 2 Sáng = %v, want [Lập trình Go]", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// The v1 API names resources in the path and takes short lower-case query
+// parameters. It is served by the same handlers as the legacy /dlu routes,
+// through legacyParams, until a later version needs its own response shapes;
+// such a version gets its own route list and leaves both of these alone.
+func v1Routes() []route {
+	week := []string{"year", "term", "week", "semester"}
+	view := append(append([]string{}, week...), "projection", "day", "session", "teacher", "subject", "include", "view", "format", "lang", "fields", "raw")
+	return []route{
+		{Method: http.MethodGet, Path: "/v1/classes/:classID/schedule", Query: view, handlers: []gin.HandlerFunc{legacyParams("classID", "ClassStudentID"), scheduleHandler}, response: dluparser.Schedule{}},
+		{Method: http.MethodGet, Path: "/v1/classes/:classID/exams", Query: []string{"year", "term", "semester"}, handlers: []gin.HandlerFunc{legacyParams("classID", "ClassStudentID"), examsHandler}},
+		{Method: http.MethodGet, Path: "/v1/teachers/:teacherID/schedule", Query: view, handlers: []gin.HandlerFunc{legacyParams("teacherID", "TeacherID"), teacherHandler}, response: dluparser.Schedule{}},
+	}
+}
+
+var v1QueryNames = map[string]string{"year": "YearStudy", "term": "TermID", "week": "Week"}
+
+// legacyParams rewrites a v1 request into the query the legacy handlers read:
+// the path parameter becomes the named query parameter and v1 names are
+// renamed. It must run before anything reads the query, since gin caches it.
+func legacyParams(param, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := c.Request.URL.Query()
+		for v1, legacy := range v1QueryNames {
+			if vals, ok := q[v1]; ok {
+				q[legacy] = vals
+				delete(q, v1)
+			}
+		}
+		q.Set(name, c.Param(param))
+		c.Request.URL.RawQuery = q.Encode()
+		c.Next()
+	}
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// Subscriptions are kept in DLU_SUBSCRIPTIONS_FILE when it is set, so they
+// survive restarts. Callbacks resolving to loopback, private or link-local
+// addresses are refused unless DLU_WEBHOOK_ALLOW_PRIVATE is set.
+var (
+	pollInterval        = envDuration("DLU_POLL_INTERVAL", 15*time.Minute)
+	maxSubscriptions    = envInt("DLU_MAX_SUBSCRIPTIONS", 1000)
+	subscriptionsFile   = getenv("DLU_SUBSCRIPTIONS_FILE")
+	webhookAllowPrivate = envBool("DLU_WEBHOOK_ALLOW_PRIVATE", false)
+	webhookWorkers      = envInt("DLU_WEBHOOK_WORKERS", 4)
+	webhookQueueSize    = envInt("DLU_WEBHOOK_QUEUE", 1000)
+)
+
+// webhookClient delivers change events. It is separate from the upstream
+// client, whose TLS settings are specific to the portal, and checks every
+// address it connects to, redirects included, so a callback can't be pointed
+// at the server's own network after it was validated.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(_, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || blockedCallbackIP(ip) {
+					return fmt.Errorf("callback address %s is not allowed", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+type subscriptionRequest struct {
+	YearStudy      string `json:"YearStudy"`
+	TermID         string `json:"TermID"`
+	Week           string `json:"Week"`
+	Semester       string `json:"semester"`
+	ClassStudentID string `json:"ClassStudentID"`
+	CallbackURL    string `json:"callback_url"`
+}
+
+type subscription struct {
+	ID string `json:"id"`
+	// Owner is the API key that created the subscription, or "admin".
+	Owner       string        `json:"owner"`
+	Query       scheduleQuery `json:"query"`
+	CallbackURL string        `json:"callback_url"`
+	// Secret signs deliveries; it is only shown when the subscription is
+	// created.
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// last is the schedule the next poll is compared with. It is unset for
+	// subscriptions loaded from the file until the first poll takes it.
+	last dluparser.Schedule
+}
+
+// subscriptionInfo is a subscription as listed, without its secret.
+type subscriptionInfo struct {
+	ID             string    `json:"id"`
+	CallbackURL    string    `json:"callback_url"`
+	YearStudy      string    `json:"YearStudy"`
+	TermID         string    `json:"TermID"`
+	Week           string    `json:"Week"`
+	ClassStudentID string    `json:"ClassStudentID"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (sub *subscription) info() subscriptionInfo {
+	return subscriptionInfo{
+		ID:             sub.ID,
+		CallbackURL:    sub.CallbackURL,
+		YearStudy:      sub.Query.YearStudy,
+		TermID:         sub.Query.TermID,
+		Week:           sub.Query.Week,
+		ClassStudentID: sub.Query.ClassStudentID,
+		CreatedAt:      sub.CreatedAt,
+	}
+}
+
+// changeEvent is the body POSTed to a callback, signed with HMAC-SHA256 of
+// the subscription secret in X-DLU-Signature ("sha256=<hex>").
+type changeEvent struct {
+	Event          string             `json:"event"`
+	SubscriptionID string             `json:"subscription_id,omitempty"`
+	YearStudy      string             `json:"YearStudy"`
+	TermID         string             `json:"TermID"`
+	Week           string             `json:"Week"`
+	ClassStudentID string             `json:"ClassStudentID"`
+	Checksum       string             `json:"checksum"`
+	Changes        []dluparser.Change `json:"changes,omitempty"`
+	// Schedule is the new week's schedule on "week.rollover" events.
+	Schedule *dluparser.Schedule `json:"schedule,omitempty"`
+}
+
+var (
+	subsMu        sync.Mutex
+	subscriptions = make(map[string]*subscription)
+)
+
+func loadSubscriptions(path string) (map[string]*subscription, error) {
+	subs := make(map[string]*subscription)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return subs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*subscription
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+	for _, sub := range list {
+		subs[sub.ID] = sub
+	}
+	return subs, nil
+}
+
+// saveSubscriptions writes DLU_SUBSCRIPTIONS_FILE through a temporary file.
+// The caller holds subsMu.
+func saveSubscriptions() error {
+	if subscriptionsFile == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(sortedSubscriptions(""), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := subscriptionsFile + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, subscriptionsFile)
+}
+
+// sortedSubscriptions lists owner's subscriptions, or every one for "",
+// oldest first. The caller holds subsMu.
+func sortedSubscriptions(owner string) []*subscription {
+	out := []*subscription{}
+	for _, sub := range subscriptions {
+		if owner == "" || sub.Owner == owner {
+			out = append(out, sub)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// requireSubscriber lets through requests carrying the admin key or an API
+// key, recording which as "subscriber". Admins see every subscription; a key
+// only its own.
+func requireSubscriber(c *gin.Context) {
+	if adminKey == "" && apiKeys == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	if key := c.GetHeader("X-Admin-Key"); adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+		c.Set("subscriber", "admin")
+	} else if id := c.GetString("apiKey"); id != "" {
+		c.Set("subscriber", id)
+	} else {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key or admin key required"})
+		return
+	}
+	c.Next()
+}
+
+// ownerFilter is who a request's subscriptions are filtered by: "" for an
+// admin, who sees them all.
+func ownerFilter(c *gin.Context) string {
+	if owner := c.GetString("subscriber"); owner != "admin" {
+		return owner
+	}
+	return ""
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// blockedCallbackIP reports addresses on the server's own side of the
+// network, which callbacks may not reach.
+func blockedCallbackIP(ip net.IP) bool {
+	if webhookAllowPrivate {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is RFC 6598's carrier-grade NAT range, private in all
+// but name.
+var sharedAddressSpace = &net.IPNet{IP: net.IP{100, 64, 0, 0}, Mask: net.CIDRMask(10, 32)}
+
+// validCallback checks that a callback is an absolute http(s) URL whose host
+// resolves only to public addresses. webhookClient checks again when it
+// connects, since DNS may answer differently by then.
+func validCallback(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid callback_url %q, expected an absolute http(s) URL", raw)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("callback_url host %q does not resolve", u.Hostname())
+	}
+	for _, a := range addrs {
+		if blockedCallbackIP(a.IP) {
+			return fmt.Errorf("callback_url host %q resolves to %s, which is not allowed", u.Hostname(), a.IP)
+		}
+	}
+	return nil
+}
+
+// createSubscriptionHandler registers a callback for one class and week. It
+// fetches the schedule once up front, both to validate the request and to
+// take the snapshot later polls are compared with.
+func createSubscriptionHandler(c *gin.Context) {
+	var req subscriptionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.ClassStudentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMissingParams.Error()})
+		return
+	}
+	q, err := scheduleQuery{
+		YearStudy:      req.YearStudy,
+		TermID:         req.TermID,
+		Week:           req.Week,
+		Semester:       req.Semester,
+		ClassStudentID: req.ClassStudentID,
+	}.resolve()
+	if err == nil {
+		err = validCallback(c.Request.Context(), req.CallbackURL)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subsMu.Lock()
+	full := len(subscriptions) >= maxSubscriptions
+	subsMu.Unlock()
+	if full {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Subscription limit reached"})
+		return
+	}
+
+	schedule, err := loadSchedule(c.Request.Context(), q)
+	if err != nil {
+		upstreamError(c, err)
+		return
+	}
+
+	sub := &subscription{
+		ID:          randomHex(8),
+		Owner:       c.GetString("subscriber"),
+		Query:       q,
+		CallbackURL: req.CallbackURL,
+		Secret:      randomHex(16),
+		CreatedAt:   time.Now().UTC(),
+		last:        schedule,
+	}
+	subsMu.Lock()
+	subscriptions[sub.ID] = sub
+	err = saveSubscriptions()
+	if err != nil {
+		delete(subscriptions, sub.ID)
+	}
+	subsMu.Unlock()
+	if err != nil {
+		log.Printf("webhooks: save subscriptions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not save the subscription"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":             sub.ID,
+		"callback_url":   sub.CallbackURL,
+		"secret":         sub.Secret,
+		"YearStudy":      q.YearStudy,
+		"TermID":         q.TermID,
+		"Week":           q.Week,
+		"ClassStudentID": q.ClassStudentID,
+	})
+}
+
+func listSubscriptionsHandler(c *gin.Context) {
+	subsMu.Lock()
+	out := []subscriptionInfo{}
+	for _, sub := range sortedSubscriptions(ownerFilter(c)) {
+		out = append(out, sub.info())
+	}
+	subsMu.Unlock()
+	c.JSON(http.StatusOK, out)
+}
+
+// deleteSubscriptionHandler removes a subscription. Another key's
+// subscriptions are reported as unknown rather than forbidden.
+func deleteSubscriptionHandler(c *gin.Context) {
+	owner := ownerFilter(c)
+	subsMu.Lock()
+	sub, ok := subscriptions[c.Param("id")]
+	ok = ok && (owner == "" || sub.Owner == owner)
+	var err error
+	if ok {
+		delete(subscriptions, sub.ID)
+		if err = saveSubscriptions(); err != nil {
+			subscriptions[sub.ID] = sub
+		}
+	}
+	subsMu.Unlock()
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown subscription"})
+		return
+	}
+	if err != nil {
+		log.Printf("webhooks: save subscriptions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not save the change"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// pollSubscriptions re-fetches every subscribed or streamed schedule each
+// interval and delivers the differences. Subscriptions and streams for the
+// same class and week share one fetch.
+func pollSubscriptions() {
+	for i := 0; i < webhookWorkers; i++ {
+		go deliverQueued()
+	}
+	for range time.Tick(pollInterval) {
+		subsMu.Lock()
+		byQuery := make(map[scheduleQuery][]*subscription)
+		for _, sub := range subscriptions {
+			byQuery[sub.Query] = append(byQuery[sub.Query], sub)
+		}
+		subsMu.Unlock()
+		rollWatchers(time.Now())
+		watching := watchersByQuery()
+		for q := range watching {
+			if _, ok := byQuery[q]; !ok {
+				byQuery[q] = nil
+			}
+		}
+
+		for q, subs := range byQuery {
+			schedule, err := loadSchedule(context.Background(), q)
+			if err != nil {
+				log.Printf("webhooks: fetch %s week %s: %v", q.ClassStudentID, q.Week, err)
+				continue
+			}
+			for _, w := range watching[q] {
+				w.update(schedule)
+			}
+			for _, sub := range subs {
+				prev := sub.last
+				sub.last = schedule
+				if prev.Meta == nil {
+					continue
+				}
+				changes := dluparser.Diff(prev, schedule)
+				if len(changes) == 0 {
+					continue
+				}
+				enqueueDelivery(sub, changeEvent{
+					Event:          "schedule.changed",
+					SubscriptionID: sub.ID,
+					YearStudy:      q.YearStudy,
+					TermID:         q.TermID,
+					Week:           q.Week,
+					ClassStudentID: q.ClassStudentID,
+					Checksum:       schedule.Meta.Checksum,
+					Changes:        changes,
+				})
+			}
+		}
+	}
+}
+
+type delivery struct {
+	sub   *subscription
+	event changeEvent
+}
+
+// deliveries queues events for webhookWorkers to POST, so a slow or
+// unreachable callback holds up its own deliveries and not the poller.
+var deliveries = make(chan delivery, webhookQueueSize)
+
+// enqueueDelivery queues an event, dropping it when the queue is full.
+func enqueueDelivery(sub *subscription, event changeEvent) {
+	select {
+	case deliveries <- delivery{sub, event}:
+	default:
+		log.Printf("webhooks: queue full, dropping %s event for %s", event.Event, sub.ID)
+	}
+}
+
+func deliverQueued() {
+	for d := range deliveries {
+		deliver(d.sub, d.event)
+	}
+}
+
+func deliver(sub *subscription, event changeEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhooks: encode event for %s: %v", sub.ID, err)
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(sub.Secret))
+	mac.Write(body)
+
+	req, err := http.NewRequest(http.MethodPost, sub.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("webhooks: request for %s: %v", sub.ID, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-DLU-Event", event.Event)
+	req.Header.Set("X-DLU-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		log.Printf("webhooks: deliver to %s: %v", sub.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("webhooks: %s answered %s", sub.ID, resp.Status)
+	}
+}
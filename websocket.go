@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// A minimal RFC 6455 server: enough for JSON text messages and keepalive,
+// without extensions or subprotocols.
+
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxWSMessage = 64 << 10
+)
+
+var (
+	wsPingInterval     = envDuration("DLU_WS_PING", 30*time.Second)
+	wsMaxSubscriptions = envInt("DLU_WS_MAX_SUBSCRIPTIONS", 20)
+)
+
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	wmu  sync.Mutex
+	w    *bufio.Writer
+}
+
+func upgradeWebSocket(c *gin.Context) (*wsConn, error) {
+	h := c.Request.Header
+	key := h.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(h.Get("Upgrade"), "websocket") || !strings.Contains(strings.ToLower(h.Get("Connection")), "upgrade") || key == "" {
+		return nil, errors.New("expected a WebSocket upgrade request")
+	}
+	if h.Get("Sec-WebSocket-Version") != "13" {
+		c.Header("Sec-WebSocket-Version", "13")
+		return nil, errors.New("unsupported WebSocket version")
+	}
+
+	c.Status(http.StatusSwitchingProtocols)
+	conn, rw, err := http.NewResponseController(c.Writer).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// Drop the server's read and write timeouts, which are meant for ordinary
+	// requests; keepalive takes over from here.
+	conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, r: rw.Reader, w: rw.Writer}, nil
+}
+
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	ws.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	ws.w.Write(header)
+	ws.w.Write(payload)
+	return ws.w.Flush()
+}
+
+func (ws *wsConn) writeJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.writeFrame(wsText, b)
+}
+
+func (ws *wsConn) close(code uint16, reason string) {
+	ws.writeFrame(wsClose, append(binary.BigEndian.AppendUint16(nil, code), reason...))
+	ws.conn.Close()
+}
+
+// readMessage returns the next text or binary message, answering pings and
+// reassembling fragments along the way. Every frame, pongs included, extends
+// the read deadline, so a client that stops answering pings is dropped after
+// two intervals.
+func (ws *wsConn) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		ws.conn.SetReadDeadline(time.Now().Add(2 * wsPingInterval))
+		var head [2]byte
+		if _, err := io.ReadFull(ws.r, head[:]); err != nil {
+			return nil, err
+		}
+		fin, opcode := head[0]&0x80 != 0, head[0]&0x0F
+		if head[1]&0x80 == 0 {
+			return nil, errors.New("unmasked client frame")
+		}
+		n := uint64(head[1] & 0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if n > maxWSMessage || uint64(len(msg))+n > maxWSMessage {
+			return nil, errors.New("message too large")
+		}
+		var mask [4]byte
+		if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+			return nil, err
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(ws.r, payload); err != nil {
+			return nil, err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := ws.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			// Echo the status code before tearing down (RFC 6455 §5.5.1).
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			ws.writeFrame(wsClose, payload)
+			return nil, io.EOF
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// wsCommand is a client message: {"action": "subscribe", ...} with the /dlu
+// parameters (Week may be left out to follow the current week),
+// {"action": "unsubscribe", "id": ...} or {"action": "list"}.
+type wsCommand struct {
+	Action         string `json:"action"`
+	ID             string `json:"id"`
+	YearStudy      string `json:"YearStudy"`
+	TermID         string `json:"TermID"`
+	Week           string `json:"Week"`
+	Semester       string `json:"semester"`
+	ClassStudentID string `json:"ClassStudentID"`
+}
+
+type wsSubscription struct {
+	ID             string `json:"id"`
+	YearStudy      string `json:"YearStudy"`
+	TermID         string `json:"TermID"`
+	Week           string `json:"Week"`
+	ClassStudentID string `json:"ClassStudentID"`
+	Follow         bool   `json:"follow_current_week"`
+	Checksum       string `json:"checksum,omitempty"`
+}
+
+// wsSession is one connection's set of watchers.
+type wsSession struct {
+	ws     *wsConn
+	events chan changeEvent
+	subs   map[string]*watcher
+	nextID int
+	// loaded carries first fetches back to the session loop; pending counts
+	// the ones still in flight against the subscription limit.
+	loaded  chan wsLoaded
+	pending int
+}
+
+type wsLoaded struct {
+	query    scheduleQuery
+	follow   bool
+	schedule dluparser.Schedule
+	err      error
+}
+
+func (s *wsSession) handle(c *gin.Context, raw []byte) {
+	var cmd wsCommand
+	if err := json.Unmarshal(raw, &cmd); err != nil {
+		s.fail("invalid message: " + err.Error())
+		return
+	}
+	switch cmd.Action {
+	case "subscribe":
+		s.subscribe(c, cmd)
+	case "unsubscribe":
+		w, ok := s.subs[cmd.ID]
+		if !ok {
+			s.fail("unknown subscription " + cmd.ID)
+			return
+		}
+		removeWatcher(w)
+		delete(s.subs, cmd.ID)
+		s.ws.writeJSON(gin.H{"event": "unsubscribed", "id": cmd.ID})
+	case "list":
+		subs := []wsSubscription{}
+		for _, w := range s.subs {
+			subs = append(subs, s.describe(w))
+		}
+		sort.Slice(subs, func(i, j int) bool {
+			a, _ := strconv.Atoi(subs[i].ID)
+			b, _ := strconv.Atoi(subs[j].ID)
+			return a < b
+		})
+		s.ws.writeJSON(gin.H{"event": "subscriptions", "subscriptions": subs})
+	default:
+		s.fail(fmt.Sprintf("unknown action %q", cmd.Action))
+	}
+}
+
+// subscribe validates cmd and fetches the first schedule in the background,
+// so the session loop keeps answering pings, events and commands meanwhile;
+// the result comes back through s.loaded to added.
+func (s *wsSession) subscribe(c *gin.Context, cmd wsCommand) {
+	if len(s.subs)+s.pending >= wsMaxSubscriptions {
+		s.fail(fmt.Sprintf("at most %d subscriptions per connection", wsMaxSubscriptions))
+		return
+	}
+	if cmd.ClassStudentID == "" {
+		s.fail(errMissingParams.Error())
+		return
+	}
+	q := scheduleQuery{YearStudy: cmd.YearStudy, TermID: cmd.TermID, Week: cmd.Week, Semester: cmd.Semester, ClassStudentID: cmd.ClassStudentID}
+	follow := q.Week == "" || strings.EqualFold(q.Week, "current")
+	if follow {
+		q.Week = "current"
+	}
+	q, err := q.resolve()
+	if err != nil {
+		s.fail(err.Error())
+		return
+	}
+
+	s.pending++
+	ctx := c.Request.Context()
+	go func() {
+		schedule, err := loadSchedule(ctx, q)
+		select {
+		case s.loaded <- wsLoaded{query: q, follow: follow, schedule: schedule, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (s *wsSession) added(r wsLoaded) {
+	s.pending--
+	if r.err != nil {
+		s.fail(r.err.Error())
+		return
+	}
+	s.nextID++
+	w := &watcher{id: strconv.Itoa(s.nextID), query: r.query, last: r.schedule, events: s.events, follow: r.follow}
+	if !addWatcher(w) {
+		s.fail("Stream limit reached")
+		return
+	}
+	s.subs[w.id] = w
+	sub := s.describe(w)
+	sub.Checksum = r.schedule.Meta.Checksum
+	s.ws.writeJSON(gin.H{"event": "subscribed", "subscription": sub})
+}
+
+func (s *wsSession) describe(w *watcher) wsSubscription {
+	watchMu.Lock()
+	q := w.query
+	watchMu.Unlock()
+	return wsSubscription{
+		ID:             w.id,
+		YearStudy:      q.YearStudy,
+		TermID:         q.TermID,
+		Week:           q.Week,
+		ClassStudentID: q.ClassStudentID,
+		Follow:         w.follow,
+	}
+}
+
+func (s *wsSession) fail(msg string) {
+	s.ws.writeJSON(gin.H{"event": "error", "error": msg})
+}
+
+// wsHandler upgrades /ws to a WebSocket on which clients subscribe to
+// classes and receive the same change events as /dlu/stream, plus a
+// "week.rollover" event for subscriptions that follow the current week.
+func wsHandler(c *gin.Context) {
+	ws, err := upgradeWebSocket(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer ws.conn.Close()
+
+	s := &wsSession{ws: ws, events: make(chan changeEvent, streamBuffer), subs: map[string]*watcher{}, loaded: make(chan wsLoaded)}
+	defer func() {
+		for _, w := range s.subs {
+			removeWatcher(w)
+		}
+	}()
+
+	messages := make(chan []byte)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			msg, err := ws.readMessage()
+			if err != nil {
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-c.Request.Context().Done():
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-streamsClosing:
+			ws.close(1001, "server shutting down")
+			return
+		case msg := <-messages:
+			s.handle(c, msg)
+		case r := <-s.loaded:
+			s.added(r)
+		case ev := <-s.events:
+			ws.writeJSON(ev)
+		case <-ping.C:
+			if err := ws.writeFrame(wsPing, nil); err != nil {
+				return
+			}
+		}
+	}
+}
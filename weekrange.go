@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRangeWeeks caps how many weeks one range request may load.
+var maxRangeWeeks = envInt("DLU_MAX_RANGE_WEEKS", 30)
+
+type weekResult struct {
+	Week     string              `json:"week"`
+	Schedule *dluparser.Schedule `json:"schedule,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+type weekRangeResponse struct {
+	Class  string         `json:"class"`
+	Meta   dluparser.Meta `json:"meta"`
+	Weeks  []weekResult   `json:"weeks"`
+	Failed []int          `json:"failed_weeks,omitempty"`
+	// Resume is this request narrowed to the failed weeks. Weeks that did
+	// load are cached, so retrying the whole range is cheap too.
+	Resume string `json:"resume,omitempty"`
+}
+
+// bindWeekRange reads fromWeek/toWeek, or an explicit weeks=1,5,6 list as
+// given in a resume link.
+func bindWeekRange(c *gin.Context) ([]int, error) {
+	var weeks []int
+	if list := c.Query("weeks"); list != "" {
+		for _, part := range strings.Split(list, ",") {
+			w, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("invalid week %q in weeks", part)
+			}
+			if weeks = append(weeks, w); len(weeks) > maxRangeWeeks {
+				return nil, fmt.Errorf("at most %d weeks per request", maxRangeWeeks)
+			}
+		}
+	} else {
+		from, err := strconv.Atoi(c.Query("fromWeek"))
+		if err != nil || from <= 0 {
+			return nil, fmt.Errorf("fromWeek and toWeek (or weeks) are required")
+		}
+		to, err := strconv.Atoi(c.Query("toWeek"))
+		if err != nil || to < from {
+			return nil, fmt.Errorf("toWeek must be a week number no earlier than fromWeek")
+		}
+		if to-from+1 > maxRangeWeeks {
+			return nil, fmt.Errorf("at most %d weeks per request", maxRangeWeeks)
+		}
+		for w := from; w <= to; w++ {
+			weeks = append(weeks, w)
+		}
+	}
+	return weeks, nil
+}
+
+// fetchWeeks loads several weeks of one class concurrently. Each fetch still
+// goes through the upstream limiter, so a long range paces itself.
+func fetchWeeks(ctx context.Context, base scheduleQuery, weeks []int) []classResult {
+	results := make([]classResult, len(weeks))
+	fetchWeeksEach(ctx, base, weeks, func(i int, res classResult) { results[i] = res })
+	return results
+}
+
+// fetchWeeksEach is fetchWeeks handing each week to done as soon as it
+// finishes. done is called from the caller's goroutine, one week at a time,
+// with the week's index in weeks.
+func fetchWeeksEach(ctx context.Context, base scheduleQuery, weeks []int, done func(i int, res classResult)) {
+	type indexed struct {
+		i   int
+		res classResult
+	}
+	finished := make(chan indexed)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, aggregateWorkers)
+	for i, w := range weeks {
+		wg.Add(1)
+		go func(i, w int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			q := base
+			q.Week = strconv.Itoa(w)
+			s, err := loadSchedule(ctx, q)
+			finished <- indexed{i, classResult{Schedule: s, Err: err}}
+		}(i, w)
+	}
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	for f := range finished {
+		done(f.i, f.res)
+	}
+}
+
+// streamWeeks writes the range as NDJSON, one line per week in the order the
+// fetches finish: the week's Schedule, or {"week", "error"} when it failed.
+func streamWeeks(c *gin.Context, q scheduleQuery, weeks []int) {
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	enc := json.NewEncoder(c.Writer)
+	fetchWeeksEach(c.Request.Context(), q, weeks, func(i int, res classResult) {
+		if res.Err != nil {
+			enc.Encode(weekResult{Week: strconv.Itoa(weeks[i]), Error: res.Err.Error()})
+		} else {
+			enc.Encode(res.Schedule)
+		}
+		c.Writer.Flush()
+	})
+}
+
+// weekRangeHandler returns a run of weeks for one class. Weeks that fail are
+// reported alongside the rest instead of failing the request; only when
+// every week fails is the upstream error returned. With ?stream=ndjson the
+// weeks are streamed instead; see streamWeeks.
+func weekRangeHandler(c *gin.Context) {
+	q, err := bindTermQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	weeks, err := bindWeekRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch stream := c.Query("stream"); stream {
+	case "":
+	case "ndjson":
+		streamWeeks(c, q, weeks)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown stream %q, expected ndjson", stream)})
+		return
+	}
+
+	resp := weekRangeResponse{
+		Class: q.ClassStudentID,
+		Meta:  dluparser.Meta{YearStudy: q.YearStudy, TermID: q.TermID, Semester: q.Semester},
+		Weeks: make([]weekResult, len(weeks)),
+	}
+	var firstErr error
+	for i, res := range fetchWeeks(c.Request.Context(), q, weeks) {
+		resp.Weeks[i].Week = strconv.Itoa(weeks[i])
+		if res.Err != nil {
+			resp.Weeks[i].Error = res.Err.Error()
+			resp.Failed = append(resp.Failed, weeks[i])
+			if firstErr == nil {
+				firstErr = res.Err
+			}
+			continue
+		}
+		resp.Weeks[i].Schedule = &res.Schedule
+	}
+	if len(resp.Failed) == len(weeks) {
+		upstreamError(c, firstErr)
+		return
+	}
+
+	if len(resp.Failed) > 0 {
+		v := c.Request.URL.Query()
+		v.Del("fromWeek")
+		v.Del("toWeek")
+		failed := make([]string, len(resp.Failed))
+		for i, w := range resp.Failed {
+			failed[i] = strconv.Itoa(w)
+		}
+		v.Set("weeks", strings.Join(failed, ","))
+		resp.Resume = c.Request.URL.Path + "?" + v.Encode()
+	}
+	c.JSON(http.StatusOK, resp)
+}
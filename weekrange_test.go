@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSemesterNDJSON(t *testing.T) {
+	prevAttempts := retryAttempts
+	t.Cleanup(func() { retryAttempts = prevAttempts })
+	retryAttempts = 1
+
+	// Week 1 is slow and week 2 fails, so week 1 comes last.
+	page, err := os.ReadFile("pkg/dluparser/testdata/mau2.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		week := r.URL.Query().Get("Week")
+		switch week {
+		case "1":
+			time.Sleep(time.Second)
+		case "2":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(strings.Replace(string(page), "Tuần 3", "Tuần "+week, 1)))
+	})
+	rec := get(t, h, "/dlu/semester?YearStudy=2024-2025&TermID=HK01&ClassStudentID=CTK45&fromWeek=1&toWeek=3&stream=ndjson")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type %q", ct)
+	}
+
+	var got []string
+	sc := bufio.NewScanner(rec.Body)
+	for sc.Scan() {
+		var line struct {
+			Week  string          `json:"week"`
+			Error string          `json:"error"`
+			Days  json.RawMessage `json:"days"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		switch {
+		case line.Error != "":
+			got = append(got, line.Week+":error")
+		case len(line.Days) > 0:
+			got = append(got, line.Week+":schedule")
+		default:
+			t.Errorf("line %q is neither a schedule nor an error", sc.Text())
+		}
+	}
+	if len(got) != 3 || got[2] != "1:schedule" || !slices.Contains(got, "2:error") || !slices.Contains(got, "3:schedule") {
+		t.Errorf("lines = %v, want 2:error and 3:schedule followed by 1:schedule", got)
+	}
+
+	if rec := get(t, h, "/dlu/semester?YearStudy=2024-2025&TermID=HK01&ClassStudentID=CTK45&fromWeek=1&toWeek=3&stream=sse"); rec.Code != http.StatusBadRequest {
+		t.Errorf("stream=sse: status %d, want 400", rec.Code)
+	}
+}
+
+func TestWeekRangeCap(t *testing.T) {
+	prev := maxRangeWeeks
+	t.Cleanup(func() { maxRangeWeeks = prev })
+	maxRangeWeeks = 4
+	h := newTestServer(t, servePage(t, "mau2.html"))
+
+	tests := []struct {
+		name, params string
+		want         int
+	}{
+		{"range at the cap", "fromWeek=1&toWeek=4", http.StatusOK},
+		{"range over the cap", "fromWeek=1&toWeek=5", http.StatusBadRequest},
+		{"huge range", "fromWeek=1&toWeek=2000000000", http.StatusBadRequest},
+		{"list at the cap", "weeks=1,3,5,7", http.StatusOK},
+		{"list over the cap", "weeks=1,2,3,4,5", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := get(t, h, "/dlu/semester?YearStudy=2024-2025&TermID=HK01&ClassStudentID=CTK45&"+tt.params)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if tt.want != http.StatusBadRequest {
+			continue
+		}
+		var body struct{ Error string }
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Error != "at most 4 weeks per request" {
+			t.Errorf("%s: error %q", tt.name, body.Error)
+		}
+	}
+}
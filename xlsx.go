@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/xuri/excelize/v2"
+)
+
+// The workbook has one sheet with a title row, session and period header
+// rows, then Monday→Sunday with each session merged across the periods it
+// takes. The grid is laid out in an xlsxSheet first, since overlapping
+// sessions share a cell, and then written with excelize.
+
+const (
+	xlsxTitleRow  = 1
+	xlsxHeaderRow = 2
+	xlsxPeriodRow = 3
+	xlsxFirstDay  = 4
+)
+
+// Cell styles, indexes into xlsxStyles.
+const (
+	xlsxPlain = iota
+	xlsxTitle
+	xlsxHeader
+	xlsxSession
+	xlsxEmpty
+)
+
+type xlsxCell struct {
+	text  string
+	style int
+}
+
+type xlsxSheet struct {
+	cells  map[[2]int]*xlsxCell // {row, col}, both from 1
+	merges [][3]int             // {row, fromCol, toCol}
+}
+
+func (sh *xlsxSheet) set(row, col int, text string, style int) {
+	sh.cells[[2]int{row, col}] = &xlsxCell{text, style}
+}
+
+func (sh *xlsxSheet) merge(row, fromCol, toCol int) {
+	if toCol > fromCol {
+		sh.merges = append(sh.merges, [3]int{row, fromCol, toCol})
+	}
+}
+
+func xlsxRef(row, col int) string {
+	ref, _ := excelize.CoordinatesToCellName(col, row)
+	return ref
+}
+
+func sessionText(sub dluparser.Subject) string {
+	lines := []string{sub.Name}
+	if sub.Code != "" {
+		lines[0] += " (" + sub.Code + ")"
+	}
+	if sub.Room != "" {
+		lines = append(lines, "Phòng: "+sub.Room)
+	}
+	if sub.Teacher != "" {
+		lines = append(lines, "GV: "+sub.Teacher)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderXLSX(s dluparser.Schedule) ([]byte, error) {
+	periods := 0
+	for p := range periodTimes {
+		periods = max(periods, p)
+	}
+	for _, d := range s.Days {
+		for _, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range slot {
+				periods = max(periods, sub.PeriodEnd)
+			}
+		}
+	}
+	// Column 1 holds the day; period p is column p+1.
+	lastCol := periods + 1
+
+	sh := &xlsxSheet{cells: map[[2]int]*xlsxCell{}}
+	sh.set(xlsxTitleRow, 1, strings.TrimSpace(fmt.Sprintf("Thời khóa biểu lớp %s – Tuần %s", s.Class, s.Week)), xlsxTitle)
+	sh.merge(xlsxTitleRow, 1, lastCol)
+	sh.set(xlsxHeaderRow, 1, "Thứ", xlsxHeader)
+	sh.set(xlsxPeriodRow, 1, "Tiết", xlsxHeader)
+
+	slotCols := xlsxSessionCols(periods)
+	for p := 1; p <= periods; p++ {
+		label := strconv.Itoa(p)
+		if t, ok := periodTimes[p]; ok {
+			label += "\n" + t.Start.String()
+		}
+		sh.set(xlsxPeriodRow, p+1, label, xlsxHeader)
+	}
+	for i, cols := range slotCols {
+		if cols[0] != 0 {
+			sh.set(xlsxHeaderRow, cols[0], dluparser.Slots[i], xlsxHeader)
+			sh.merge(xlsxHeaderRow, cols[0], cols[1])
+		}
+	}
+
+	byIndex := make(map[int]dluparser.DaySchedule, len(s.Days))
+	for name, d := range s.Days {
+		if n, ok := dluparser.WeekdayIndex(name); ok {
+			byIndex[n] = dluparser.MergeDays(byIndex[n], d)
+		}
+	}
+	for n := 1; n <= 7; n++ {
+		row := xlsxFirstDay + n - 1
+		sh.set(row, 1, dluparser.VietnameseDayNames[n], xlsxHeader)
+		// owner[col] is the column where the session covering col starts, so
+		// sessions that overlap share one cell instead of overlapping merges.
+		owner := make(map[int]int)
+		d := byIndex[n]
+		for i, slot := range [][]dluparser.Subject{d.Sang, d.Chieu, d.Toi} {
+			for _, sub := range slot {
+				// A session such as "1-3,7" gets a merged cell per run of
+				// consecutive periods; one without periods fills its slot.
+				var spans [][2]int
+				for _, run := range periodRuns(sub.Periods) {
+					spans = append(spans, [2]int{run[0] + 1, run[1] + 1})
+				}
+				if len(spans) == 0 && slotCols[i][0] != 0 {
+					spans = [][2]int{slotCols[i]}
+				}
+				for _, span := range spans {
+					from, to := span[0], span[1]
+					if start, taken := firstOwner(owner, from, to); taken {
+						c := sh.cells[[2]int{row, start}]
+						c.text += "\n\n" + sessionText(sub)
+						continue
+					}
+					for col := from; col <= to; col++ {
+						owner[col] = from
+					}
+					sh.set(row, from, sessionText(sub), xlsxSession)
+					sh.merge(row, from, to)
+				}
+			}
+		}
+		for col := 2; col <= lastCol; col++ {
+			if _, ok := owner[col]; !ok {
+				sh.set(row, col, "", xlsxEmpty)
+			} else if owner[col] != col {
+				// Covered cells of a merge still need the border.
+				sh.set(row, col, "", xlsxSession)
+			}
+		}
+	}
+
+	return sh.write(lastCol, xlsxFirstDay+6)
+}
+
+// xlsxSessionCols is the first and last column of each session for periods
+// 1..periods, period p being column p+1.
+func xlsxSessionCols(periods int) [3][2]int {
+	var cols [3][2]int
+	for p := 1; p <= periods; p++ {
+		i := periodSession(p)
+		if cols[i][0] == 0 {
+			cols[i][0] = p + 1
+		}
+		cols[i][1] = p + 1
+	}
+	return cols
+}
+
+func firstOwner(owner map[int]int, from, to int) (int, bool) {
+	for col := from; col <= to; col++ {
+		if start, ok := owner[col]; ok {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+// xlsxStyles are, in order, the plain, title, header, session and empty
+// cell styles.
+var xlsxStyles = func() []*excelize.Style {
+	border := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1},
+		{Type: "top", Color: "000000", Style: 1},
+		{Type: "bottom", Color: "000000", Style: 1},
+	}
+	grid := &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true}
+	return []*excelize.Style{
+		xlsxPlain: {Font: &excelize.Font{Family: "Arial", Size: 10}},
+		xlsxTitle: {
+			Font:      &excelize.Font{Family: "Arial", Size: 14, Bold: true},
+			Alignment: &excelize.Alignment{Horizontal: "center"},
+		},
+		xlsxHeader: {
+			Font:      &excelize.Font{Family: "Arial", Size: 10, Bold: true},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+			Border:    border,
+			Alignment: grid,
+		},
+		xlsxSession: {
+			Font:      &excelize.Font{Family: "Arial", Size: 10},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFF2CC"}},
+			Border:    border,
+			Alignment: grid,
+		},
+		xlsxEmpty: {Font: &excelize.Font{Family: "Arial", Size: 10}, Border: border},
+	}
+}()
+
+// write lays the sheet out as an A4 landscape workbook.
+func (sh *xlsxSheet) write(lastCol, lastRow int) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	const sheet = "TKB"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+
+	styles := make([]int, len(xlsxStyles))
+	for i, st := range xlsxStyles {
+		id, err := f.NewStyle(st)
+		if err != nil {
+			return nil, err
+		}
+		styles[i] = id
+	}
+
+	lastName, err := excelize.ColumnNumberToName(lastCol)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.SetColWidth(sheet, "A", "A", 10); err != nil {
+		return nil, err
+	}
+	if err := f.SetColWidth(sheet, "B", lastName, 9); err != nil {
+		return nil, err
+	}
+	for row := xlsxFirstDay; row <= lastRow; row++ {
+		if err := f.SetRowHeight(sheet, row, 90); err != nil {
+			return nil, err
+		}
+	}
+
+	for pos, c := range sh.cells {
+		ref := xlsxRef(pos[0], pos[1])
+		if c.text != "" {
+			if err := f.SetCellStr(sheet, ref, c.text); err != nil {
+				return nil, err
+			}
+		}
+		if err := f.SetCellStyle(sheet, ref, ref, styles[c.style]); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range sh.merges {
+		if err := f.MergeCell(sheet, xlsxRef(m[0], m[1]), xlsxRef(m[0], m[2])); err != nil {
+			return nil, err
+		}
+	}
+
+	orientation, size := "landscape", 9 // A4
+	if err := f.SetPageLayout(sheet, &excelize.PageLayoutOptions{Orientation: &orientation, Size: &size}); err != nil {
+		return nil, err
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
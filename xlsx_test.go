@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"dlu-api/pkg/dluparser"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRenderXLSX(t *testing.T) {
+	s := dluparser.Schedule{Class: "CTK45", Week: "3", Days: map[string]dluparser.DaySchedule{
+		"Thứ 2": {Sang: []dluparser.Subject{{Name: "Lập trình Go", Code: "INF123", Room: "A1.101", PeriodStart: 1, PeriodEnd: 3, Periods: []int{1, 2, 3}}}},
+		"Thứ 4": {
+			Chieu: []dluparser.Subject{{Name: "Cơ sở dữ liệu", PeriodStart: 7, PeriodEnd: 8, Periods: []int{7, 8}}},
+			// Runs past the last period of the bell schedule.
+			Toi: []dluparser.Subject{{Name: "Đồ án", PeriodStart: 14, PeriodEnd: 16, Periods: []int{14, 15, 16}}},
+		},
+	}}
+	data, err := renderXLSX(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	merged, err := f.GetMergeCells("TKB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range merged {
+		got = append(got, m.GetStartAxis()+":"+m.GetEndAxis()+"="+m.GetCellValue())
+	}
+	slices.Sort(got)
+	want := []string{
+		"A1:Q1=Thời khóa biểu lớp CTK45 – Tuần 3",
+		"B2:F2=Sáng",
+		"B4:D4=Lập trình Go (INF123)\nPhòng: A1.101",
+		"G2:K2=Chiều",
+		"H6:I6=Cơ sở dữ liệu",
+		"L2:Q2=Tối",
+		"O6:Q6=Đồ án",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("merged cells:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for ref, want := range map[string]string{"A4": "Thứ 2", "A10": "Chủ nhật", "P3": "15", "B3": "1\n07:00"} {
+		if v, _ := f.GetCellValue("TKB", ref); v != want {
+			t.Errorf("%s = %q, want %q", ref, v, want)
+		}
+	}
+}